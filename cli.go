@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hadisjane/confessly/internal/configs"
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/service"
+	"github.com/hadisjane/confessly/utils"
+)
+
+// adminPasswordEnv holds the password for the admin commands, otherwise it
+// is read from stdin
+const adminPasswordEnv = "CONFESSLY_ADMIN_PASSWORD"
+
+const usage = `Usage:
+  confessly [--config FILE] [command]
+
+  confessly                        start the server
+  confessly migrate up             apply all pending migrations
+  confessly migrate down [N]       roll back the last N migrations (default 1)
+  confessly migrate status         show applied and pending migrations
+  confessly migrate force VERSION  mark the schema as being at VERSION without running SQL
+  confessly admin create --username NAME --email EMAIL [--role ROLE]
+                                   create a staff account; the role defaults to owner
+                                   while there is none, admin otherwise
+  confessly admin password --username NAME
+                                   set a new password for an account
+
+  The admin commands read the password from $CONFESSLY_ADMIN_PASSWORD or stdin.
+`
+
+// runCommand executes a CLI subcommand and returns the process exit code
+func runCommand(args []string) int {
+	switch args[0] {
+	case "migrate":
+		if err := runMigrate(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+			return 1
+		}
+		return 0
+	case "admin":
+		if err := runAdmin(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "admin: %v\n", err)
+			return 1
+		}
+		return 0
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+}
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing subcommand\n\n%s", usage)
+	}
+
+	if err := configs.ReadSettings(configPath); err != nil {
+		return fmt.Errorf("failed to load configurations: %w", err)
+	}
+	if err := db.ConnDB(); err != nil {
+		return err
+	}
+	defer db.CloseDB()
+
+	switch args[0] {
+	case "up":
+		n, err := db.MigrateUp()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s)\n", n)
+		// The search column depends on the config, not on a migration
+		if err := db.SyncSearchColumn(); err != nil {
+			return err
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		n, err := db.MigrateDown(steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %d migration(s)\n", n)
+
+	case "status":
+		statuses, err := db.MigrationsStatus()
+		if err != nil {
+			return err
+		}
+		printMigrationsStatus(statuses)
+
+	case "force":
+		if len(args) < 2 {
+			return fmt.Errorf("force requires a version")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := db.ForceMigrationVersion(version); err != nil {
+			return err
+		}
+		fmt.Printf("Schema version forced to %d\n", version)
+
+	default:
+		return fmt.Errorf("unknown subcommand %q\n\n%s", args[0], usage)
+	}
+
+	return nil
+}
+
+func runAdmin(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing subcommand\n\n%s", usage)
+	}
+
+	fs := flag.NewFlagSet("admin "+args[0], flag.ContinueOnError)
+	username := fs.String("username", "", "username of the account")
+	var email, role *string
+	switch args[0] {
+	case "create":
+		email = fs.String("email", "", "email of the account")
+		role = fs.String("role", "", "moderator, admin or owner")
+	case "password":
+	default:
+		return fmt.Errorf("unknown subcommand %q\n\n%s", args[0], usage)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *username == "" || (email != nil && *email == "") {
+		return fmt.Errorf("--username and --email are required\n\n%s", usage)
+	}
+
+	password, err := readAdminPassword()
+	if err != nil {
+		return err
+	}
+
+	if err := configs.ReadSettings(configPath); err != nil {
+		return fmt.Errorf("failed to load configurations: %w", err)
+	}
+	if err := db.ConnDB(); err != nil {
+		return err
+	}
+	defer db.CloseDB()
+
+	// The account may be the first thing done with a fresh database
+	if _, err := db.MigrateUp(); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		user, err := service.CreateStaffAccount(*username, *email, password, *role)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %s %q with ID %d\n", user.Role, user.Username, user.ID)
+
+	case "password":
+		if err := service.SetPassword(*username, password); err != nil {
+			return err
+		}
+		fmt.Printf("Password of %q changed, its sessions have ended\n", *username)
+	}
+
+	return nil
+}
+
+// readAdminPassword takes the password from the environment or the first
+// line of stdin. Passwords have no place in the arguments, they end up in
+// the shell history and the process list.
+func readAdminPassword() (string, error) {
+	if password, ok := os.LookupEnv(adminPasswordEnv); ok {
+		return password, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password (at least ", utils.MinStrongPasswordLength, " characters): ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", fmt.Errorf("failed to read the password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func printMigrationsStatus(statuses []db.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+
+	for _, s := range statuses {
+		state, appliedAt := "pending", "-"
+		if s.Applied {
+			state = "applied"
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		switch {
+		case s.Dirty:
+			state = "dirty"
+		case s.Applied && s.Checksum == "":
+			state = "missing file"
+		case s.ChecksumMismatch:
+			state = "checksum mismatch"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+
+	w.Flush()
+}
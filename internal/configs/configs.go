@@ -1,28 +1,195 @@
 package configs
 
 import (
-	"github.com/hadisjane/confessly/internal/models"
 	"encoding/json"
+	"errors"
 	"fmt"
-	//"github.com/joho/godotenv"
 	"log"
+	"net"
+	"net/mail"
+	"net/url"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/joho/godotenv"
 )
 
+// DefaultConfigPath is used when neither --config nor CONFESSLY_CONFIG is set
+const DefaultConfigPath = "internal/configs/configs.json"
+
+// ConfigPathEnv names the environment variable with the config file path
+const ConfigPathEnv = "CONFESSLY_CONFIG"
+
 var AppSettings models.Configs
 
-func ReadSettings() error {
+var searchLanguageRe = regexp.MustCompile(`^[a-z_]+$`)
+
+// Defaults returns the built-in settings every other layer is applied on top of
+func Defaults() models.Configs {
+	return models.Configs{
+		AuthParams: models.AuthParams{
+			JwtTtlMinutes:                15,
+			RefreshTtlHours:              30 * 24,
+			EmailVerifyTtlHours:          48,
+			PasswordResetTtlMinutes:      60,
+			TwoFactorChallengeTtlSeconds: 300,
+			BootstrapToken:               true,
+		},
+		LogParams: models.LogParams{
+			LogDirectory:     "logs",
+			LogInfo:          "info.log",
+			LogError:         "error.log",
+			LogWarn:          "warn.log",
+			LogDebug:         "debug.log",
+			MaxSizeMegabytes: 10,
+			MaxBackups:       4,
+			MaxAgeDays:       30,
+			Compress:         true,
+			LocalTime:        true,
+		},
+		AppParams: models.AppParams{
+			ServerURL:  "localhost",
+			ServerName: "Confessly",
+			PortRun:    ":8081",
+			GinMode:    "debug",
+		},
+		PostgresParams: models.PostgresParams{
+			User:         "postgres",
+			Host:         "localhost",
+			Port:         "5432",
+			Database:     "confessly",
+			SSLMode:      "disable",
+			MaxOpenConns: 10,
+			MaxIdleConns: 5,
+		},
+		PageParams: models.PageConfig{
+			DefaultLimit: 20,
+			MaxLimit:     100,
+		},
+		SearchParams: models.SearchParams{
+			Languages:      []string{"russian", "english"},
+			HighlightStart: "<mark>",
+			HighlightStop:  "</mark>",
+		},
+		CommentParams: models.CommentParams{
+			MaxDepth:  5,
+			MaxLength: 2000,
+		},
+		GuestParams: models.GuestParams{
+			EditWindowMinutes: 60,
+			CookieMaxAgeDays:  30,
+			CookieSecure:      true,
+			CookieSameSite:    "lax",
+		},
+		RateLimitParams: models.RateLimitParams{
+			Enabled: true,
+			Backend: models.RateLimitMemory,
+			Policies: map[string]models.RateLimitPolicy{
+				models.RateLimitCreateConfession: {Requests: 5, PerSeconds: 3600, Key: models.RateLimitByIdentity},
+				models.RateLimitCreateComment:    {Requests: 30, PerSeconds: 3600, Key: models.RateLimitByIdentity},
+				models.RateLimitCreateReport:     {Requests: 10, PerSeconds: 3600, Key: models.RateLimitByIdentity},
+				models.RateLimitLogin:            {Requests: 10, PerSeconds: 900, Key: models.RateLimitByIP},
+				models.RateLimitRegister:         {Requests: 5, PerSeconds: 3600, Key: models.RateLimitByIP},
+				models.RateLimitNewGuest:         {Requests: 20, PerSeconds: 3600, Key: models.RateLimitByIP},
+				models.RateLimitForgotPassword:   {Requests: 5, PerSeconds: 3600, Key: models.RateLimitByIP},
+				models.RateLimitResendVerify:     {Requests: 3, PerSeconds: 3600, Key: models.RateLimitByIdentity},
+			},
+		},
+		ChallengeParams: models.ChallengeParams{
+			Enabled:        true,
+			BaseDifficulty: 18,
+			MaxDifficulty:  24,
+			TTLSeconds:     300,
+			VolumeStep:     50,
+		},
+		ModerationParams: models.ModerationParams{
+			Mode: models.ModerationPost,
+		},
+		AutoHideParams: models.AutoHideParams{
+			Enabled: true,
+			Rules: []models.AutoHideRule{
+				{Reporters: 3, WindowMinutes: 60},
+				{Reporters: 8, WindowMinutes: 1440},
+			},
+			NewAccountHours:  72,
+			NewAccountWeight: 0.5,
+		},
+		DuplicateParams: models.DuplicateParams{
+			Enabled:     true,
+			WindowHours: 24,
+			MaxDistance: 12,
+			Action:      models.DuplicateModerate,
+			MinLength:   40,
+		},
+		MailParams: models.MailParams{
+			Backend:     models.MailLog,
+			From:        "Confessly <no-reply@localhost>",
+			LinkBaseURL: "http://localhost:8081",
+			SMTPPort:    587,
+			FilePath:    "logs/mail.log",
+		},
+		LockoutParams: models.LockoutParams{
+			Enabled:            true,
+			AccountMaxFailures: 10,
+			IPMaxFailures:      50,
+			BaseDelaySeconds:   1,
+			MaxDelaySeconds:    60,
+			LockMinutes:        15,
+			WindowMinutes:      60,
+		},
+	}
+}
+
+// ReadSettings loads AppSettings in layers: built-in defaults, then the config
+// file, then environment variables. path comes from the --config flag; when it
+// is empty CONFESSLY_CONFIG and then DefaultConfigPath are tried. All problems
+// found in the result are reported together in one error.
+func ReadSettings(path string) error {
 	fmt.Println("Loading .env file")
 
 	// Просто грузим .env в переменные окружения
-	// err := godotenv.Load()
-	// if err != nil {
-	// 	fmt.Println(".env file not found, using system environment variables")
-	// }
+	if err := godotenv.Load(); err != nil {
+		fmt.Println(".env file not found, using system environment variables")
+	}
+
+	settings := Defaults()
+
+	if err := readConfigFile(&settings, path); err != nil {
+		return err
+	}
+
+	problems := applyEnv(reflect.ValueOf(&settings).Elem())
+	problems = append(problems, validate(settings)...)
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+
+	AppSettings = settings
+	return nil
+}
 
-	fmt.Println("Reading settings file: configs/configs.json")
-	configFile, err := os.Open("internal/configs/configs.json")
+func readConfigFile(settings *models.Configs, path string) error {
+	explicit := true
+	if path == "" {
+		path = os.Getenv(ConfigPathEnv)
+	}
+	if path == "" {
+		path, explicit = DefaultConfigPath, false
+	}
+
+	fmt.Printf("Reading settings file: %s\n", path)
+	configFile, err := os.Open(path)
 	if err != nil {
+		// Only a file the user asked for has to exist
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			fmt.Println("Settings file not found, using defaults and environment variables")
+			return nil
+		}
 		return fmt.Errorf("Couldn't open config file: %s", err.Error())
 	}
 	defer func(configFile *os.File) {
@@ -32,9 +199,229 @@ func ReadSettings() error {
 		}
 	}(configFile)
 
-	if err = json.NewDecoder(configFile).Decode(&AppSettings); err != nil {
+	decoder := json.NewDecoder(configFile)
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(settings); err != nil {
 		return fmt.Errorf("Couldn't decode json config: %s", err.Error())
 	}
 
 	return nil
 }
+
+// applyEnv overrides struct fields from the environment variables named in
+// their env tags and returns the values that could not be parsed
+func applyEnv(v reflect.Value) []string {
+	var problems []string
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			problems = append(problems, applyEnv(value)...)
+			continue
+		}
+
+		tag := field.Tag.Get("env")
+		if tag == "" {
+			continue
+		}
+
+		for _, name := range strings.Split(tag, ",") {
+			raw, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+			if err := setFromEnv(value, raw); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			}
+			break
+		}
+	}
+
+	return problems
+}
+
+func setFromEnv(value reflect.Value, raw string) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		value.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		value.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		value.SetBool(b)
+	case reflect.Map:
+		// Structured settings are given as JSON. A map keeps the entries the
+		// variable doesn't name, so one policy can be changed alone.
+		if err := json.Unmarshal([]byte(raw), value.Addr().Interface()); err != nil {
+			return fmt.Errorf("invalid JSON: %v", err)
+		}
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.String {
+			// A JSON list replaces the whole list
+			if err := json.Unmarshal([]byte(raw), value.Addr().Interface()); err != nil {
+				return fmt.Errorf("invalid JSON: %v", err)
+			}
+			return nil
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+func validate(s models.Configs) []string {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	auth := s.AuthParams
+	check(auth.JwtSecretKey != "", "auth_params.jwt_secret_key is required (or set JWT_SECRET_KEY)")
+	check(auth.JwtTtlMinutes > 0, "auth_params.jwt_ttl_minutes must be positive")
+	check(auth.RefreshTtlHours > 0, "auth_params.refresh_ttl_hours must be positive")
+	check(auth.EmailVerifyTtlHours > 0, "auth_params.email_verify_ttl_hours must be positive")
+	check(auth.PasswordResetTtlMinutes > 0, "auth_params.password_reset_ttl_minutes must be positive")
+	check(auth.TwoFactorChallengeTtlSeconds > 0, "auth_params.two_factor_challenge_ttl_seconds must be positive")
+
+	logs := s.LogParams
+	check(logs.LogDirectory != "", "log_params.log_directory is required")
+	check(logs.LogInfo != "" && logs.LogError != "" && logs.LogWarn != "" && logs.LogDebug != "",
+		"log_params log file names are required")
+	check(logs.MaxSizeMegabytes > 0, "log_params.max_size_megabytes must be positive")
+	check(logs.MaxBackups >= 0, "log_params.max_backups must not be negative")
+	check(logs.MaxAgeDays >= 0, "log_params.max_age_days must not be negative")
+
+	app := s.AppParams
+	check(app.GinMode == "debug" || app.GinMode == "release" || app.GinMode == "test",
+		"app_params.gin_mode must be debug, release or test, got %q", app.GinMode)
+	port := strings.TrimPrefix(app.PortRun, ":")
+	_, err := strconv.Atoi(port)
+	check(err == nil, "app_params.port_run must be a port like :8081, got %q", app.PortRun)
+	for i, proxy := range app.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(cidrErr == nil || net.ParseIP(proxy) != nil,
+			"app_params.trusted_proxies[%d] must be an IP address or CIDR range, got %q", i, proxy)
+	}
+
+	pg := s.PostgresParams
+	check(pg.Host != "", "postgres_params.host is required")
+	_, err = strconv.Atoi(pg.Port)
+	check(err == nil, "postgres_params.port must be a number, got %q", pg.Port)
+	check(pg.User != "", "postgres_params.user is required")
+	check(pg.Database != "", "postgres_params.database is required")
+	check(pg.SSLMode != "", "postgres_params.ssl_mode is required")
+	check(pg.MaxOpenConns > 0, "postgres_params.max_open_conns must be positive")
+	check(pg.MaxIdleConns >= 0, "postgres_params.max_idle_conns must not be negative")
+
+	page := s.PageParams
+	check(page.DefaultLimit > 0, "page_params.default_limit must be positive")
+	check(page.MaxLimit >= page.DefaultLimit, "page_params.max_limit must not be less than default_limit")
+
+	search := s.SearchParams
+	check(len(search.Languages) > 0, "search_params.languages must not be empty")
+	for _, lang := range search.Languages {
+		check(searchLanguageRe.MatchString(lang), "search_params.languages: invalid text search configuration %q", lang)
+	}
+
+	comments := s.CommentParams
+	check(comments.MaxDepth >= 0, "comment_params.max_depth must not be negative")
+	check(comments.MaxLength > 0, "comment_params.max_length must be positive")
+
+	guests := s.GuestParams
+	check(guests.EditWindowMinutes > 0, "guest_params.edit_window_minutes must be positive")
+	check(len(guests.CookieKeys) > 0, "guest_params.cookie_keys is required (or set CONFESSLY_GUEST_COOKIE_KEYS)")
+	for i, key := range guests.CookieKeys {
+		check(len(key) >= 32, "guest_params.cookie_keys[%d] must be at least 32 characters long", i)
+	}
+	check(guests.CookieMaxAgeDays > 0, "guest_params.cookie_max_age_days must be positive")
+	check(guests.CookieSameSite == "lax" || guests.CookieSameSite == "strict" || guests.CookieSameSite == "none",
+		"guest_params.cookie_same_site must be lax, strict or none, got %q", guests.CookieSameSite)
+	check(guests.CookieSameSite != "none" || guests.CookieSecure,
+		"guest_params.cookie_same_site none requires cookie_secure")
+
+	limits := s.RateLimitParams
+	check(limits.Backend == models.RateLimitMemory || limits.Backend == models.RateLimitPostgres,
+		"rate_limit_params.backend must be memory or postgres, got %q", limits.Backend)
+	for name, policy := range limits.Policies {
+		check(slices.Contains(models.RateLimitPolicyNames, name), "rate_limit_params.policies: unknown policy %q", name)
+		check(policy.Requests > 0, "rate_limit_params.policies.%s.requests must be positive", name)
+		check(policy.PerSeconds > 0, "rate_limit_params.policies.%s.per_seconds must be positive", name)
+		check(policy.Key == models.RateLimitByIdentity || policy.Key == models.RateLimitByIP,
+			"rate_limit_params.policies.%s.key must be identity or ip, got %q", name, policy.Key)
+	}
+
+	challenge := s.ChallengeParams
+	check(challenge.BaseDifficulty >= 0, "challenge_params.base_difficulty must not be negative")
+	check(challenge.MaxDifficulty >= challenge.BaseDifficulty && challenge.MaxDifficulty <= 32,
+		"challenge_params.max_difficulty must be between base_difficulty and 32")
+	check(challenge.TTLSeconds > 0, "challenge_params.ttl_seconds must be positive")
+	check(challenge.VolumeStep > 0, "challenge_params.volume_step must be positive")
+
+	moderation := s.ModerationParams
+	check(moderation.Mode == models.ModerationPost || moderation.Mode == models.ModerationPreGuests || moderation.Mode == models.ModerationPreAll,
+		"moderation_params.mode must be post, pre_guests or pre_all, got %q", moderation.Mode)
+
+	autoHide := s.AutoHideParams
+	check(!autoHide.Enabled || len(autoHide.Rules) > 0, "auto_hide_params.rules must not be empty")
+	for i, rule := range autoHide.Rules {
+		check(rule.Reporters > 0, "auto_hide_params.rules[%d].reporters must be positive", i)
+		check(rule.WindowMinutes > 0, "auto_hide_params.rules[%d].window_minutes must be positive", i)
+	}
+	check(autoHide.NewAccountHours >= 0, "auto_hide_params.new_account_hours must not be negative")
+	check(autoHide.NewAccountWeight > 0 && autoHide.NewAccountWeight <= 1,
+		"auto_hide_params.new_account_weight must be greater than 0 and at most 1")
+
+	duplicates := s.DuplicateParams
+	check(duplicates.WindowHours > 0, "duplicate_params.window_hours must be positive")
+	check(duplicates.MaxDistance >= 0 && duplicates.MaxDistance <= 64, "duplicate_params.max_distance must be between 0 and 64")
+	check(duplicates.Action == models.DuplicateReject || duplicates.Action == models.DuplicateModerate,
+		"duplicate_params.action must be reject or moderate, got %q", duplicates.Action)
+	check(duplicates.MinLength >= 0, "duplicate_params.min_length must not be negative")
+
+	mailParams := s.MailParams
+	check(mailParams.Backend == models.MailSMTP || mailParams.Backend == models.MailFile || mailParams.Backend == models.MailLog,
+		"mail_params.backend must be smtp, file or log, got %q", mailParams.Backend)
+	_, err = mail.ParseAddress(mailParams.From)
+	check(err == nil, "mail_params.from must be an email address, got %q", mailParams.From)
+	link, err := url.Parse(mailParams.LinkBaseURL)
+	check(err == nil && link.IsAbs(), "mail_params.link_base_url must be an absolute URL, got %q", mailParams.LinkBaseURL)
+	if mailParams.Backend == models.MailSMTP {
+		check(mailParams.SMTPHost != "", "mail_params.smtp_host is required for the smtp backend")
+		check(mailParams.SMTPPort > 0 && mailParams.SMTPPort <= 65535, "mail_params.smtp_port must be a port number")
+	}
+	check(mailParams.Backend != models.MailFile || mailParams.FilePath != "", "mail_params.file_path is required for the file backend")
+
+	lockout := s.LockoutParams
+	check(lockout.AccountMaxFailures > 0, "lockout_params.account_max_failures must be positive")
+	check(lockout.IPMaxFailures > 0, "lockout_params.ip_max_failures must be positive")
+	check(lockout.BaseDelaySeconds >= 0, "lockout_params.base_delay_seconds must not be negative")
+	check(lockout.MaxDelaySeconds >= lockout.BaseDelaySeconds, "lockout_params.max_delay_seconds must not be less than base_delay_seconds")
+	check(lockout.LockMinutes > 0, "lockout_params.lock_minutes must be positive")
+	check(lockout.WindowMinutes > 0, "lockout_params.window_minutes must be positive")
+
+	return problems
+}
@@ -1,10 +1,11 @@
 package controller
 
 import (
+	"errors"
 	"github.com/hadisjane/confessly/internal/errs"
-	"github.com/hadisjane/confessly/internal/middleware"
 	"github.com/hadisjane/confessly/internal/models"
 	"github.com/hadisjane/confessly/internal/service"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -12,43 +13,59 @@ import (
 )
 
 // GetReports godoc
-// @Summary Получение всех жалоб (только для администраторов)
+// @Summary Получение всех жалоб (для модераторов и администраторов)
 // @Tags admin
 // @Produce json
-// @Success 200 {object} []models.Report
+// @Param limit query int false "Page size (capped by the server)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort order" Enums(new, old)
+// @Success 200 {object} models.ReportPage
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /admin/reports [get]
 func GetReports(c *gin.Context) {
-	reports := service.GetReports()
-	if reports == nil {
-		HandleError(c, errs.ErrNotFound)
+	params, err := getPageParams(c)
+	if err != nil {
+		HandleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"reports": reports,
-	})		
+
+	page, err := service.GetReports(params)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
 }
 
 // GetUsers godoc
-// @Summary Получение всех пользователей (только для администраторов)
+// @Summary Получение всех пользователей (для модераторов и администраторов)
 // @Tags admin
 // @Produce json
-// @Success 200 {object} []models.User
+// @Param limit query int false "Page size (capped by the server)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort order" Enums(new, old)
+// @Success 200 {object} models.UserPage
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /admin/users [get]
 func GetUsers(c *gin.Context) {
-	users := service.GetUsers()
-	if users == nil {
-		HandleError(c, errs.ErrNotFound)
+	params, err := getPageParams(c)
+	if err != nil {
+		HandleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"users": users,
-	})		
+
+	page, err := service.GetUsers(params)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
 }
 
 // GetUserByID godoc
-// @Summary Получение пользователя по ID (только для администраторов)
+// @Summary Получение пользователя по ID (для модераторов и администраторов)
 // @Tags admin
 // @Produce json
 // @Param id path int true "User ID"
@@ -76,21 +93,15 @@ func GetUserByID(c *gin.Context) {
 }
 
 // DeleteConfessionByAdmin godoc
-// @Summary Удаление конфесии по ID (только для администраторов)
+// @Summary Удаление конфесии по ID (для модераторов и администраторов)
 // @Tags admin
 // @Param id path int true "Confession ID"
+// @Param body body models.ModerationReason false "Reason for the audit log"
 // @Success 200 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /admin/confessions/{id} [delete]
 func DeleteConfessionByAdmin(c *gin.Context) {
-	// Get user role from context
-	userRole, exists := c.Get(middleware.RoleCtx)
-	if !exists || userRole != "admin" {
-		HandleError(c, errs.ErrForbidden)
-		return
-	}
-
 	confessionID, err := strconv.Atoi(c.Param("id"))
 	if err != nil || confessionID <= 0 {
 		HandleError(c, errs.ErrInvalidId)
@@ -104,8 +115,14 @@ func DeleteConfessionByAdmin(c *gin.Context) {
 		return
 	}
 
+	reason, err := getModerationReason(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
 	// Delete the confession
-	if err := service.DeleteConfessionByAdmin(confessionID); err != nil {
+	if err := service.DeleteConfessionByAdmin(confessionID, getActor(c), reason); err != nil {
 		HandleError(c, err)
 		return
 	}
@@ -116,61 +133,31 @@ func DeleteConfessionByAdmin(c *gin.Context) {
 }
 
 // BanUser godoc
-// @Summary Бан пользователя (только для администраторов)
+// @Summary Бан пользователя (для модераторов и администраторов)
 // @Tags admin
+// @Accept json
 // @Param id path int true "User ID"
+// @Param body body models.BanRequest false "Scope, reason and expiry of the ban"
 // @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /admin/users/{id}/ban [post]
 func BanUser(c *gin.Context) {
-	// Get user role and ID from context
-	userRole, exists := c.Get(middleware.RoleCtx)
-	if !exists || userRole != "admin" {
-		HandleError(c, errs.ErrForbidden)
-		return
-	}
-
-	// Get admin's ID from context
-	adminID, exists := c.Get(middleware.UserIDCtx)
-	if !exists {
-		HandleError(c, errs.ErrUnauthorized)
-		return
-	}
-
 	targetUserID, err := strconv.Atoi(c.Param("id"))
 	if err != nil || targetUserID <= 0 {
 		HandleError(c, errs.ErrInvalidId)
 		return
 	}
 
-	// Prevent admin from banning themselves
-	if adminID == targetUserID {
-		HandleError(c, errs.ErrYouCannotBanYourself)
-		return
-	}
-
-	// First check if user exists and get current ban status
-	user, err := service.GetUser(targetUserID)
+	req, err := getBanRequest(c)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	// Prevent admin from banning other admins
-	if user.Role == "admin" {
-		HandleError(c, errs.ErrYouCannotBanOtherAdmin)
-		return
-	}
-
-	// Check if user is already banned
-	if user.Banned {
-		HandleError(c, errs.ErrUserAlreadyBanned)
-		return
-	}
-
 	// Ban the user
-	if err := service.BanUser(targetUserID); err != nil {
+	if err := service.BanUser(targetUserID, req, getActor(c)); err != nil {
 		HandleError(c, err)
 		return
 	}
@@ -181,42 +168,29 @@ func BanUser(c *gin.Context) {
 }
 
 // UnbanUser godoc
-// @Summary Разбан пользователя (только для администраторов)
+// @Summary Разбан пользователя (для модераторов и администраторов)
 // @Tags admin
 // @Param id path int true "User ID"
+// @Param body body models.ModerationReason false "Reason for the audit log"
 // @Success 200 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /admin/users/{id}/unban [post]
 func UnbanUser(c *gin.Context) {
-	// Get user role from context
-	userRole, exists := c.Get(middleware.RoleCtx)
-	if !exists || userRole != "admin" {
-		HandleError(c, errs.ErrForbidden)
-		return
-	}
-
 	userID, err := strconv.Atoi(c.Param("id"))
 	if err != nil || userID <= 0 {
 		HandleError(c, errs.ErrInvalidId)
 		return
 	}
 
-	// First check if user exists and get current ban status
-	user, err := service.GetUser(userID)
+	reason, err := getModerationReason(c)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	// Check if user is already unbanned
-	if !user.Banned {
-		HandleError(c, errs.ErrUserNotBanned)
-		return
-	}
-
 	// Unban the user
-	if err := service.UnbanUser(userID); err != nil {
+	if err := service.UnbanUser(userID, getActor(c), reason); err != nil {
 		HandleError(c, err)
 		return
 	}
@@ -227,21 +201,17 @@ func UnbanUser(c *gin.Context) {
 }
 
 // BanGuestUser godoc
-// @Summary Бан гостевого пользователя (только для администраторов)
+// @Summary Бан гостевого пользователя (для модераторов и администраторов)
 // @Tags admin
+// @Accept json
 // @Param uuid path string true "Guest UUID"
+// @Param body body models.BanRequest false "Scope, reason and expiry of the ban"
 // @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /admin/guest/{uuid}/ban [post]
 func BanGuestUser(c *gin.Context) {
-	// Get user role from context
-	userRole, exists := c.Get(middleware.RoleCtx)
-	if !exists || userRole != "admin" {
-		HandleError(c, errs.ErrForbidden)
-		return
-	}
-
 	uuid := c.Param("uuid")
 
 	// First check if guest user exists
@@ -251,20 +221,14 @@ func BanGuestUser(c *gin.Context) {
 		return
 	}
 
-	// Check if guest user is already banned
-	isBanned, err := service.IsGuestBanned(uuid)
+	req, err := getBanRequest(c)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	if isBanned {
-		HandleError(c, errs.ErrUserAlreadyBanned)
-		return
-	}
-
 	// Ban the guest user
-	if err := service.BanGuestUser(uuid); err != nil {
+	if err := service.BanGuestUser(uuid, req, getActor(c)); err != nil {
 		HandleError(c, err)
 		return
 	}
@@ -275,21 +239,15 @@ func BanGuestUser(c *gin.Context) {
 }
 
 // UnbanGuestUser godoc
-// @Summary Разбан гостевого пользователя (только для администраторов)
+// @Summary Разбан гостевого пользователя (для модераторов и администраторов)
 // @Tags admin
 // @Param uuid path string true "Guest UUID"
+// @Param body body models.ModerationReason false "Reason for the audit log"
 // @Success 200 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /admin/guest/{uuid}/unban [post]
 func UnbanGuestUser(c *gin.Context) {
-	// Get user role from context
-	userRole, exists := c.Get(middleware.RoleCtx)
-	if !exists || userRole != "admin" {
-		HandleError(c, errs.ErrForbidden)
-		return
-	}
-
 	uuid := c.Param("uuid")
 
 	// First check if guest user exists
@@ -299,20 +257,14 @@ func UnbanGuestUser(c *gin.Context) {
 		return
 	}
 
-	// Check if guest user is already unbanned
-	isBanned, err := service.IsGuestBanned(uuid)
+	reason, err := getModerationReason(c)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	if !isBanned {
-		HandleError(c, errs.ErrUserNotBanned)
-		return
-	}
-
 	// Unban the guest user
-	if err := service.UnbanGuestUser(uuid); err != nil {
+	if err := service.UnbanGuestUser(uuid, getActor(c), reason); err != nil {
 		HandleError(c, err)
 		return
 	}
@@ -323,29 +275,33 @@ func UnbanGuestUser(c *gin.Context) {
 }
 
 // GetGuestUsers godoc
-// @Summary Получение всех гостевых пользователей (только для администраторов)
+// @Summary Получение всех гостевых пользователей (для модераторов и администраторов)
 // @Tags admin
 // @Produce json
-// @Success 200 {object} []models.GuestUser
+// @Param limit query int false "Page size (capped by the server)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort order" Enums(new, old)
+// @Success 200 {object} models.GuestUserPage
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /admin/guests [get]
 func GetGuestUsers(c *gin.Context) {
-	guestUsers, err := service.GetGuestUsers()
+	params, err := getPageParams(c)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
-	if guestUsers == nil {
-		HandleError(c, errs.ErrNotFound)
+
+	page, err := service.GetGuestUsers(params)
+	if err != nil {
+		HandleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"guestUsers": guestUsers,
-	})		
+	c.JSON(http.StatusOK, page)
 }
 
 // GetGuestUser godoc
-// @Summary Получение гостевого пользователя по UUID (только для администраторов)
+// @Summary Получение гостевого пользователя по UUID (для модераторов и администраторов)
 // @Tags admin
 // @Produce json
 // @Param uuid path string true "Guest UUID"
@@ -368,25 +324,71 @@ func GetGuestUser(c *gin.Context) {
 	})	
 }
 
+// GetUserBans godoc
+// @Summary История банов пользователя (для модераторов и администраторов)
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string][]models.Ban
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/bans [get]
+func GetUserBans(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || userID <= 0 {
+		HandleError(c, errs.ErrInvalidId)
+		return
+	}
+
+	bans, err := service.GetUserBans(userID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bans": bans,
+	})
+}
+
+// GetGuestBans godoc
+// @Summary История банов гостевого пользователя (для модераторов и администраторов)
+// @Tags admin
+// @Produce json
+// @Param uuid path string true "Guest UUID"
+// @Success 200 {object} map[string][]models.Ban
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/guests/{uuid}/bans [get]
+func GetGuestBans(c *gin.Context) {
+	bans, err := service.GetGuestBans(c.Param("uuid"))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bans": bans,
+	})
+}
+
 // UpdateReport godoc
-// @Summary Обновление жалобы (только для администраторов)
+// @Summary Обновление жалобы (для модераторов и администраторов)
+// @Description Status moves pending → in_review → resolved or dismissed; closed reports can be reopened to pending.
+// @Description Resolving can carry an action: none, hide_confession, delete_confession, ban_author or ban_guest.
+// @Description For a report on a comment, hide and delete apply to the comment.
 // @Tags admin
 // @Accept json
 // @Produce json
 // @Param id path int true "Report ID"
 // @Param report body models.UpdateReport true "Report object"
 // @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /admin/reports/{id} [put]
 func UpdateReport(c *gin.Context) {
-	// Get user role from context
-	userRole, exists := c.Get(middleware.RoleCtx)
-	if !exists || userRole != "admin" {
-		HandleError(c, errs.ErrForbidden)
-		return
-	}
-
 	reportID, err := strconv.Atoi(c.Param("id"))
 	if err != nil || reportID <= 0 {
 		HandleError(c, errs.ErrInvalidId)
@@ -407,7 +409,7 @@ func UpdateReport(c *gin.Context) {
 	}
 
 	// Update the report
-	if err := service.UpdateReport(reportID, updateReq); err != nil {
+	if err := service.UpdateReport(reportID, getActor(c), updateReq); err != nil {
 		HandleError(c, err)
 		return
 	}
@@ -418,7 +420,7 @@ func UpdateReport(c *gin.Context) {
 }
 
 // GetReport godoc
-// @Summary Получение жалобы по ID (только для администраторов)
+// @Summary Получение жалобы по ID (для модераторов и администраторов)
 // @Tags admin
 // @Produce json
 // @Param id path int true "Report ID"
@@ -443,4 +445,14 @@ func GetReport(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"report": report,
 	})	
-}
\ No newline at end of file
+}
+
+// getBanRequest reads the optional body of a ban request; without one the
+// ban is a permanent login ban
+func getBanRequest(c *gin.Context) (models.BanRequest, error) {
+	var req models.BanRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		return models.BanRequest{}, err
+	}
+	return req, nil
+}
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/middleware"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/service"
+	"github.com/hadisjane/confessly/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuditLog godoc
+// @Summary Журнал действий модераторов (только для администраторов)
+// @Description Filters can be combined. With format=csv the whole filtered log is exported as CSV, oldest first, without pagination.
+// @Tags admin
+// @Produce json
+// @Produce text/csv
+// @Param actor_id query int false "Admin who performed the action"
+// @Param target_type query string false "Target type" Enums(user, guest, confession, comment, report, content_filter)
+// @Param target_id query string false "Target ID"
+// @Param from query string false "Start of the time range, RFC 3339"
+// @Param to query string false "End of the time range (exclusive), RFC 3339"
+// @Param format query string false "Response format" Enums(json, csv)
+// @Param limit query int false "Page size (capped by the server)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort order" Enums(new, old)
+// @Success 200 {object} models.ModerationActionPage
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/audit [get]
+func GetAuditLog(c *gin.Context) {
+	filter, err := getAuditFilter(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+	case "csv":
+		exportAuditLog(c, filter)
+		return
+	default:
+		HandleError(c, errs.ErrInvalidAuditFilter)
+		return
+	}
+
+	params, err := getPageParams(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	page, err := service.GetModerationActions(filter, params)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+var auditCSVHeader = []string{
+	"id", "created_at", "actor_id", "actor_username", "ip",
+	"action", "target_type", "target_id", "reason", "before", "after",
+}
+
+func exportAuditLog(c *gin.Context, filter models.AuditFilter) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="moderation-audit.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	if err := w.Write(auditCSVHeader); err != nil {
+		return
+	}
+
+	err := service.ExportModerationActions(filter, func(action models.ModerationAction) error {
+		username := ""
+		if action.ActorUsername != nil {
+			username = *action.ActorUsername
+		}
+		row := []string{
+			strconv.Itoa(action.ID),
+			action.CreatedAt.UTC().Format(time.RFC3339),
+			strconv.Itoa(action.ActorID),
+			username,
+			action.IP,
+			action.Action,
+			action.TargetType,
+			action.TargetID,
+			action.Reason,
+			string(action.Before),
+			string(action.After),
+		}
+		for i := range row {
+			row[i] = csvSafe(row[i])
+		}
+		return w.Write(row)
+	})
+	w.Flush()
+
+	// The status line is already sent, so a failure can only cut the file short
+	if err == nil {
+		err = w.Error()
+	}
+	if err != nil {
+		logger.Error.Printf("Error exporting audit log: %v", err)
+	}
+}
+
+// csvSafe keeps spreadsheets from running a cell as a formula. Reasons and
+// usernames come from users, so a cell starting with a formula character gets
+// a leading quote.
+func csvSafe(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func getAuditFilter(c *gin.Context) (models.AuditFilter, error) {
+	filter := models.AuditFilter{
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+	}
+
+	if actorID := c.Query("actor_id"); actorID != "" {
+		id, err := strconv.Atoi(actorID)
+		if err != nil || id <= 0 {
+			return models.AuditFilter{}, errs.ErrInvalidAuditFilter
+		}
+		filter.ActorID = id
+	}
+
+	var err error
+	if filter.From, err = getTimeQuery(c, "from"); err != nil {
+		return models.AuditFilter{}, err
+	}
+	if filter.To, err = getTimeQuery(c, "to"); err != nil {
+		return models.AuditFilter{}, err
+	}
+
+	return filter, nil
+}
+
+func getTimeQuery(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errs.ErrInvalidAuditFilter
+	}
+	return &t, nil
+}
+
+// getActor returns the admin making the request, for the audit log
+func getActor(c *gin.Context) models.Actor {
+	return models.Actor{
+		ID:   c.GetInt(middleware.UserIDCtx),
+		IP:   c.ClientIP(),
+		Role: c.GetString(middleware.RoleCtx),
+	}
+}
+
+// getModerationReason reads the optional reason from the request body
+func getModerationReason(c *gin.Context) (string, error) {
+	var req models.ModerationReason
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return req.Reason, nil
+}
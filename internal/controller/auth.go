@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/middleware"
 	"github.com/hadisjane/confessly/internal/models"
 	"github.com/hadisjane/confessly/internal/service"
 	"github.com/hadisjane/confessly/utils"
@@ -14,10 +16,12 @@ import (
 // @Tags auth
 // @Accept json
 // @Produce json
+// @Description With claim_guest set, the confessions of the guest in the guest_uuid cookie move to the new account.
 // @Param user body models.UserRegister true "User object"
-// @Success 201 {object} map[string]string
+// @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /auth/register [post]
 func Register(c *gin.Context) {
 	var u models.UserRegister
@@ -27,13 +31,28 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	if err := service.CreateUser(u); err != nil {
+	var guestUUID string
+	if u.ClaimGuest {
+		guestUUID = middleware.GuestCookie(c)
+	}
+
+	claimed, err := service.CreateUser(u, guestUUID)
+	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
+	if !u.ClaimGuest {
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "User registered successfully",
+		})
+		return
+	}
+
+	middleware.ClearGuestCookie(c)
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "User registered successfully",
+		"message":             "User registered successfully",
+		"claimed_confessions": claimed,
 	})
 
 }
@@ -43,10 +62,14 @@ func Register(c *gin.Context) {
 // @Tags auth
 // @Accept json
 // @Produce json
+// @Description With claim_guest set, the confessions of the guest in the guest_uuid cookie move to the account.
+// @Description When the account has two-factor authentication, the answer is a models.TwoFactorChallenge instead
+// @Description of tokens; the login finishes at /auth/login/2fa.
 // @Param user body models.UserLogin true "User object"
-// @Success 200 {object} map[string]string
+// @Success 200 {object} models.LoginResponse
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /auth/login [post]
 func Login(c *gin.Context) {
 	var u models.UserLogin
@@ -56,19 +79,347 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	user, err := service.GetUserByUsernameAndPassword(u.Username, u.Password)
+	user, err := service.GetUserByUsernameAndPassword(u.Username, u.Password, c.ClientIP())
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Username, user.Role)
+	twoFactor, err := service.HasTwoFactor(user.ID)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
+	if twoFactor {
+		challenge, err := service.StartLoginChallenge(user)
+		if err != nil {
+			HandleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	finishLogin(c, user, u.ClaimGuest)
+}
+
+// LoginTwoFactor godoc
+// @Summary Второй шаг входа: код двухфакторной аутентификации
+// @Description Exchanges the challenge token from /auth/login and a code from the authenticator app
+// @Description or a recovery code for the session tokens. After 5 wrong codes the password has to be entered again.
+// @Description Wrong codes count as failed logins of the account, and while it is locked out every code is refused.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body models.TwoFactorLoginRequest true "Challenge token and code"
+// @Success 200 {object} models.LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/login/2fa [post]
+func LoginTwoFactor(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	user, err := service.CompleteLoginChallenge(req.ChallengeToken, req.Code, c.ClientIP())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	finishLogin(c, user, req.ClaimGuest)
+}
+
+// finishLogin claims the guest identity if asked to and issues the tokens
+func finishLogin(c *gin.Context, user models.User, claimGuest bool) {
+	// Claim before issuing tokens so a failed claim doesn't leave a session behind
+	var claimed *int64
+	if claimGuest {
+		moved, err := service.ClaimGuest(middleware.GuestCookie(c), user.ID)
+		if err != nil {
+			HandleError(c, err)
+			return
+		}
+		claimed = &moved
+		middleware.ClearGuestCookie(c)
+	}
+
+	tokens, err := service.IssueTokens(user)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LoginResponse{
+		TokenPair:          tokens,
+		ClaimedConfessions: claimed,
+	})
+}
+
+// Refresh godoc
+// @Summary Обновление пары токенов по refresh токену
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} models.TokenPair
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/refresh [post]
+func Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	tokens, err := service.RefreshTokens(req.RefreshToken)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout godoc
+// @Summary Выход из текущей сессии
+// @Description Revokes the access token and, if given, the refresh token of this session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body models.LogoutRequest false "Refresh token of the session"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/logout [post]
+func Logout(c *gin.Context) {
+	value, _ := c.Get(middleware.ClaimsCtx)
+	claims, ok := value.(*utils.CustomClaims)
+	if !ok {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	// The body is optional
+	var req models.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			HandleError(c, err)
+			return
+		}
+	}
+
+	if err := service.Logout(claims, req.RefreshToken); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out successfully",
+	})
+}
+
+// LogoutAll godoc
+// @Summary Выход из всех сессий
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/logout-all [post]
+func LogoutAll(c *gin.Context) {
+	userID := c.GetInt(middleware.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	if err := service.LogoutAll(userID); err != nil {
+		HandleError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"access_token": token,
+		"message": "Logged out of all sessions successfully",
+	})
+}
+
+// VerifyEmail godoc
+// @Summary Подтверждение email по токену из письма
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body models.VerifyEmailRequest true "Token from the verification email"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/email/verify [post]
+func VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := service.VerifyEmail(req.Token); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email verified successfully",
+	})
+}
+
+// ResendVerificationEmail godoc
+// @Summary Повторная отправка письма для подтверждения email
+// @Description Links sent earlier stop working.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/email/resend [post]
+func ResendVerificationEmail(c *gin.Context) {
+	userID := c.GetInt(middleware.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	if err := service.ResendVerificationEmail(userID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Verification email sent",
+	})
+}
+
+// ForgotPassword godoc
+// @Summary Запрос ссылки для сброса пароля
+// @Description The answer is the same whether or not an account has this email.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param email body models.ForgotPasswordRequest true "Email of the account"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/password/forgot [post]
+func ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	service.RequestPasswordReset(req.Email)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If an account with this email exists, a password reset link has been sent to it",
+	})
+}
+
+// ResetPassword godoc
+// @Summary Установка нового пароля по токену из письма
+// @Description The token works once. Every session of the user ends.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body models.ResetPasswordRequest true "Token from the reset email and the new password"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/password/reset [post]
+func ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := service.ResetPassword(req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password changed successfully, please log in again",
+	})
+}
+
+// ChangePassword godoc
+// @Summary Смена пароля по текущему паролю
+// @Description Works without a session, so an account that has to change its password before logging in can do it.
+// @Description Banned users are refused. Accounts with two-factor authentication also need two_factor_code,
+// @Description a code from the authenticator app or a recovery code.
+// @Description Staff accounts and accounts that have to change their password need a strong password.
+// @Description Every session of the user ends.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body models.ChangePasswordRequest true "Username, current and new password, 2FA code"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/password/change [post]
+func ChangePassword(c *gin.Context) {
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := service.ChangePassword(req, c.ClientIP()); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password changed successfully, please log in again",
+	})
+}
+
+// Bootstrap godoc
+// @Summary Создание первого владельца по одноразовому токену из лога
+// @Description The token is printed to the log on start while there is no owner and works once, for 24 hours.
+// @Description The password has to be strong.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body models.BootstrapRequest true "Bootstrap token and the owner account"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/bootstrap [post]
+func Bootstrap(c *gin.Context) {
+	var req models.BootstrapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := service.Bootstrap(req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Owner created successfully, please log in",
 	})
 }
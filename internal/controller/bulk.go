@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RunBulk godoc
+// @Summary Массовые действия модерации (только для администраторов)
+// @Description Actions: delete_confession and hide_confession (confession_id or filter by user_id/guest_uuid and since/until),
+// @Description ban_user (user_id), ban_guest (guest_uuid) with optional scope and expires_at, resolve_report (report_id,
+// @Description status resolved or dismissed, report_action). Every operation runs in its own transaction; with atomic all
+// @Description of them run in one and nothing changes if one fails. The request is refused before anything runs if an
+// @Description operation is invalid.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body models.BulkRequest true "Operations"
+// @Success 200 {object} models.BulkResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/bulk [post]
+func RunBulk(c *gin.Context) {
+	var req models.BulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	response, err := service.RunBulk(req, getActor(c))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
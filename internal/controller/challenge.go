@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetChallenge godoc
+// @Summary Получение задачи proof-of-work для публикации гостем
+// @Description Find a solution such that SHA-256 of "<challenge>:<solution>" starts with difficulty zero bits,
+// @Description then send challenge and solution with the confession. Each challenge can be used once.
+// @Description Registered users don't need one and get required: false.
+// @Tags confession
+// @Produce json
+// @Success 200 {object} models.Challenge
+// @Failure 500 {object} map[string]string
+// @Router /challenge [get]
+func GetChallenge(c *gin.Context) {
+	userID, guestUUID := getIdentity(c)
+	if userID != nil || guestUUID == nil {
+		c.JSON(http.StatusOK, models.Challenge{Required: false})
+		return
+	}
+
+	challenge, err := service.NewChallenge(*guestUUID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, challenge)
+}
@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/middleware"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetComments godoc
+// @Summary Получение комментариев к конфесии
+// @Description Returns the thread as a tree: top-level comments with nested replies.
+// @Description Anonymous commenters are shown by their alias in the thread, the author of the confession is marked with is_op.
+// @Tags comment
+// @Produce json
+// @Param id path int true "Confession ID"
+// @Success 200 {object} map[string][]models.Comment
+// @Failure 404 {object} map[string]string
+// @Router /confessions/{id}/comments [get]
+func GetComments(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		HandleError(c, errs.ErrInvalidId)
+		return
+	}
+
+	userID, guestUUID := getIdentity(c)
+	thread, err := service.GetCommentThread(id, userID, guestUUID, middleware.HasPermission(c, models.PermConfessionsModerate))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if !canRevealAuthors(c) {
+		hideCommentAuthors(thread)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comments": thread,
+	})
+}
+
+// CreateComment godoc
+// @Summary Создание комментария или ответа к конфесии
+// @Description Set parent_id to reply to a comment. Guests always comment anonymously.
+// @Tags comment
+// @Accept json
+// @Produce json
+// @Param id path int true "Confession ID"
+// @Param comment body models.CreateCommentRequest true "Comment object"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /confessions/{id}/comments [post]
+func CreateComment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		HandleError(c, errs.ErrInvalidId)
+		return
+	}
+
+	var req models.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	comment := models.Comment{
+		ConfessionID: id,
+		ParentID:     req.ParentID,
+		Text:         req.Text,
+		Anon:         req.Anon,
+	}
+
+	userID, guestUUID := getIdentity(c)
+	switch {
+	case userID != nil:
+		comment.UserID = userID
+		comment.Username = c.GetString(middleware.UsernameCtx)
+	case guestUUID != nil:
+		comment.GuestUUID = guestUUID
+		comment.Username = "Guest_" + (*guestUUID)[:8]
+		comment.Anon = true
+	default:
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	created, err := service.CreateComment(comment)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if !canRevealAuthors(c) {
+		hideCommentAuthor(&created)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Comment created successfully",
+		"comment": created,
+	})
+}
+
+// UpdateComment godoc
+// @Summary Редактирование своего комментария
+// @Tags comment
+// @Accept json
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param comment body models.UpdateCommentRequest true "Comment object"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /comments/{id} [put]
+func UpdateComment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		HandleError(c, errs.ErrInvalidId)
+		return
+	}
+
+	var req models.UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	userID, guestUUID := getIdentity(c)
+	if userID == nil && guestUUID == nil {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	if err := service.UpdateComment(id, userID, guestUUID, req.Text); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment updated successfully",
+	})
+}
+
+// DeleteComment godoc
+// @Summary Удаление своего комментария
+// @Description A comment with replies is replaced by a placeholder so the thread stays intact.
+// @Tags comment
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /comments/{id} [delete]
+func DeleteComment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		HandleError(c, errs.ErrInvalidId)
+		return
+	}
+
+	userID, guestUUID := getIdentity(c)
+	if userID == nil && guestUUID == nil {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	if err := service.DeleteComment(id, userID, guestUUID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment deleted successfully",
+	})
+}
+
+// RemoveCommentByAdmin godoc
+// @Summary Удаление комментария модератором
+// @Description The comment stays in the thread as a placeholder, its text is only visible to staff who can reveal authors.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param body body models.ModerationReason false "Reason for the audit log"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/comments/{id} [delete]
+func RemoveCommentByAdmin(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		HandleError(c, errs.ErrInvalidId)
+		return
+	}
+
+	reason, err := getModerationReason(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := service.RemoveCommentByAdmin(id, getActor(c), reason); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment removed successfully by admin",
+	})
+}
+
+// hideCommentAuthors hides who wrote anonymous comments and what deleted
+// comments said, for the whole thread
+func hideCommentAuthors(comments []*models.Comment) {
+	for _, comment := range comments {
+		hideCommentAuthor(comment)
+		hideCommentAuthors(comment.Replies)
+	}
+}
+
+func hideCommentAuthor(comment *models.Comment) {
+	comment.GuestUUID = nil
+	if comment.Anon {
+		comment.UserID = nil
+		comment.Username = ""
+	}
+
+	if comment.Status != models.CommentVisible {
+		comment.UserID = nil
+		comment.Username = ""
+		comment.Alias = nil
+		comment.IsOP = false
+		comment.Text = ""
+	}
+}
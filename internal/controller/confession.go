@@ -3,6 +3,7 @@ package controller
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/hadisjane/confessly/internal/errs"
 	"github.com/hadisjane/confessly/internal/middleware"
@@ -28,16 +29,23 @@ type CreateConfessionRequest struct {
 	Title string `json:"title" binding:"required"`
 	Text  string `json:"text" binding:"required"`
 	Anon  bool   `json:"anon"`
+
+	// Guests have to solve a challenge from GET /public/challenge
+	Challenge string `json:"challenge"`
+	Solution  string `json:"solution"`
 }
 
 // CreateConfession godoc
 // @Summary Создание конфесии
+// @Description Guests must include challenge and solution, see GET /public/challenge.
 // @Tags confession
 // @Accept json
 // @Produce json
 // @Param confession body CreateConfessionRequest true "Confession object"
-// @Success 201 {object} map[string]string
+// @Description Under pre-moderation the confession is created as pending and only published once an admin approves it.
+// @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /confessions [post]
 func CreateConfession(c *gin.Context) {
 	var req CreateConfessionRequest
@@ -50,6 +58,7 @@ func CreateConfession(c *gin.Context) {
 	username, userExists := c.Get(middleware.UsernameCtx)
 
 	var confession models.Confession
+	var challenge *models.SolvedChallenge
 
 	if !userExists || userID == 0 {
 		// Handle guest user
@@ -72,6 +81,13 @@ func CreateConfession(c *gin.Context) {
 			return
 		}
 
+		// Guests prove some work instead of solving a CAPTCHA
+		challenge, err = service.VerifyChallenge(guestUUIDStr, req.Challenge, req.Solution)
+		if err != nil {
+			HandleError(c, err)
+			return
+		}
+
 		confession = models.Confession{
 			GuestUUID: &guestUUIDStr,
 			Username:  "Guest_" + guestUUIDStr[:8],
@@ -96,13 +112,16 @@ func CreateConfession(c *gin.Context) {
 		}
 	}
 
-	if err := service.CreateConfession(confession); err != nil {
+	created, err := service.CreateConfession(confession, challenge)
+	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Confession created successfully",
+		"id":      created.ID,
+		"status":  created.Status,
 	})
 }
 
@@ -110,47 +129,41 @@ func CreateConfession(c *gin.Context) {
 // @Summary Получение всех конфесий
 // @Tags confession
 // @Produce json
-// @Success 200 {object} []models.Confession
+// @Param limit query int false "Page size (capped by the server)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort order" Enums(new, old)
+// @Success 200 {object} models.ConfessionPage
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /confessions [get]
 func GetAllConfessions(c *gin.Context) {
-	userRole, _ := c.Get(middleware.RoleCtx)
-	userRoleStr, _ := userRole.(string)
-
-	confessions, err := service.GetAllConfessions()
+	params, err := getPageParams(c)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	// Hide sensitive info for non-admin users
-	if userRoleStr != "admin" {
-		for i := range confessions {
-			if confessions[i].Anon {
-				confessions[i].UserID = nil
-				confessions[i].GuestUUID = nil
-				confessions[i].Username = ""
-			}
-		}
+	page, err := service.GetAllConfessions(params)
+	if err != nil {
+		HandleError(c, err)
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"confessions": confessions,
-	})
+	hideAnonAuthors(c, page.Confessions)
+
+	c.JSON(http.StatusOK, page)
 }
 
 // GetConfession godoc
 // @Summary Получение конфесии по ID
 // @Tags confession
+// @Description Confessions that aren't published are only visible to moderators and their author.
 // @Produce json
 // @Param id path int true "Confession ID"
 // @Success 200 {object} models.Confession
 // @Failure 404 {object} map[string]string
 // @Router /confessions/{id} [get]
 func GetConfession(c *gin.Context) {
-	userRole, _ := c.Get(middleware.RoleCtx)
-	userRoleStr, _ := userRole.(string)
-
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		HandleError(c, err)
@@ -163,11 +176,15 @@ func GetConfession(c *gin.Context) {
 		return
 	}
 
-	// Hide sensitive info for non-admin users
-	if confession.Anon && userRoleStr != "admin" {
-		confession.UserID = nil
-		confession.GuestUUID = nil
-		confession.Username = ""
+	userID, guestUUID := getIdentity(c)
+	if !service.CanViewConfession(confession, userID, guestUUID, middleware.HasPermission(c, models.PermConfessionsModerate)) {
+		HandleError(c, errs.ErrConfessionNotFound)
+		return
+	}
+
+	// Hide sensitive info from users who can't reveal authors
+	if !canRevealAuthors(c) {
+		hideAnonAuthor(&confession)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -188,14 +205,17 @@ type UpdateConfessionRequest struct {
 // @Accept json
 // @Produce json
 // @Param id path int true "Confession ID"
+// @Description Guests can edit their own confessions through /public within guest_params.edit_window_minutes of posting.
+// @Description Guest confessions stay anonymous, anon is ignored for them.
+// @Description Under pre-moderation an edited confession goes back to the approval queue.
 // @Param confession body UpdateConfessionRequest true "Confession object"
 // @Success 200 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Router /confessions/{id} [put]
 func UpdateConfession(c *gin.Context) {
-	userID := c.GetInt(middleware.UserIDCtx)
-	if userID == 0 {
+	userID, guestUUID := getIdentity(c)
+	if userID == nil && guestUUID == nil {
 		HandleError(c, errs.ErrUnauthorized)
 		return
 	}
@@ -213,9 +233,9 @@ func UpdateConfession(c *gin.Context) {
 		return
 	}
 
-	// Check if the requesting user is the owner
-	if existingConfession.UserID == nil || *existingConfession.UserID != userID {
-		HandleError(c, errs.ErrForbidden)
+	// Check if the requesting user or guest is the owner
+	if err := service.CheckConfessionOwner(existingConfession, userID, guestUUID, errs.ErrForbidden); err != nil {
+		HandleError(c, err)
 		return
 	}
 
@@ -227,12 +247,14 @@ func UpdateConfession(c *gin.Context) {
 
 	// Create an update confession with existing values
 	updatedConfession := models.Confession{
-		ID:       id,
-		UserID:   &userID,
-		Username: existingConfession.Username,
-		Title:    existingConfession.Title,
-		Text:     existingConfession.Text,
-		Anon:     existingConfession.Anon,
+		ID:        id,
+		UserID:    existingConfession.UserID,
+		GuestUUID: existingConfession.GuestUUID,
+		Username:  existingConfession.Username,
+		Title:     existingConfession.Title,
+		Text:      existingConfession.Text,
+		Anon:      existingConfession.Anon,
+		Status:    existingConfession.Status,
 	}
 
 	// Update only the fields that were provided in the request
@@ -242,7 +264,9 @@ func UpdateConfession(c *gin.Context) {
 	if updateReq.Text != nil {
 		updatedConfession.Text = *updateReq.Text
 	}
-	if updateReq.Anon != nil {
+	// Guest confessions are always anonymous, showing one would publish the
+	// guest UUID, which works like a password for claims
+	if updateReq.Anon != nil && existingConfession.GuestUUID == nil {
 		updatedConfession.Anon = *updateReq.Anon
 	}
 
@@ -259,14 +283,15 @@ func UpdateConfession(c *gin.Context) {
 // DeleteConfession godoc
 // @Summary Удаление конфесии
 // @Tags confession
+// @Description Guests can delete their own confessions through /public within guest_params.edit_window_minutes of posting.
 // @Param id path int true "Confession ID"
 // @Success 200 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Router /confessions/{id} [delete]
 func DeleteConfession(c *gin.Context) {
-	userID := c.GetInt(middleware.UserIDCtx)
-	if userID == 0 {
+	userID, guestUUID := getIdentity(c)
+	if userID == nil && guestUUID == nil {
 		HandleError(c, errs.ErrUnauthorized)
 		return
 	}
@@ -284,9 +309,9 @@ func DeleteConfession(c *gin.Context) {
 		return
 	}
 
-	// Check if the requesting user is the owner
-	if confession.UserID == nil || *confession.UserID != userID {
-		HandleError(c, errs.ErrForbiddenDelete)
+	// Check if the requesting user or guest is the owner
+	if err := service.CheckConfessionOwner(confession, userID, guestUUID, errs.ErrForbiddenDelete); err != nil {
+		HandleError(c, err)
 		return
 	}
 
@@ -300,69 +325,114 @@ func DeleteConfession(c *gin.Context) {
 	})
 }
 
+// GetMyConfessions godoc
+// @Summary Получение своих конфесий
+// @Description Lists the caller's own confessions in every status, including pending and rejected ones.
+// @Tags confession
+// @Produce json
+// @Param status query string false "Only confessions in this status" Enums(pending, published, rejected, hidden)
+// @Param limit query int false "Page size (capped by the server)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort order" Enums(new, old)
+// @Success 200 {object} models.ConfessionPage
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /confessions/mine [get]
+func GetMyConfessions(c *gin.Context) {
+	userID, guestUUID := getIdentity(c)
+	if userID == nil && guestUUID == nil {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	params, err := getPageParams(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	page, err := service.GetOwnConfessions(userID, guestUUID, c.Query("status"), params)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
 // SearchConfessions godoc
-// @Summary Поиск конфесий по названию
+// @Summary Полнотекстовый поиск конфесий по заголовку и тексту
+// @Description Supports websearch syntax: "quoted phrases", -excluded words and OR.
+// @Description Without q it returns the regular confession list.
 // @Tags confession
 // @Produce json
-// @Param q query string true "Search query"
-// @Success 200 {object} []models.Confession
+// @Param q query string false "Search query"
+// @Param limit query int false "Page size (capped by the server)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort order, relevance by default" Enums(relevance, new, old)
+// @Success 200 {object} models.ConfessionSearchPage
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /confessions/search [get]
 func SearchConfessions(c *gin.Context) {
-	query := c.Query("q")
+	query := strings.TrimSpace(c.Query("q"))
+
+	params, err := getPageParams(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
 
 	if query == "" {
-		confessions, err := service.GetAllConfessions()
+		page, err := service.GetAllConfessions(params)
 		if err != nil {
 			HandleError(c, err)
 			return
 		}
 
-		// Hide sensitive info for non-admin users
-		userRole, _ := c.Get(middleware.RoleCtx)
-		userRoleStr, _ := userRole.(string)
-
-		if userRoleStr != "admin" {
-			for i := range confessions {
-				if confessions[i].Anon {
-					confessions[i].UserID = nil
-					confessions[i].GuestUUID = nil
-					confessions[i].Username = ""
-				}
-			}
-		}
+		hideAnonAuthors(c, page.Confessions)
 
-		c.JSON(http.StatusOK, gin.H{
-			"confessions": confessions,
-		})
+		c.JSON(http.StatusOK, page)
 		return
 	}
 
-	confessions, err := service.SearchConfessionsByTitle(query)
+	page, err := service.SearchConfessions(query, params)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	if confessions == nil {
-		confessions = []models.Confession{}
+	if !canRevealAuthors(c) {
+		for i := range page.Confessions {
+			hideAnonAuthor(&page.Confessions[i].Confession)
+		}
 	}
 
-	// Hide sensitive info for non-admin users
-	userRole, _ := c.Get(middleware.RoleCtx)
-	userRoleStr, _ := userRole.(string)
+	c.JSON(http.StatusOK, page)
+}
 
-	if userRoleStr != "admin" {
-		for i := range confessions {
-			if confessions[i].Anon {
-				confessions[i].UserID = nil
-				confessions[i].GuestUUID = nil
-				confessions[i].Username = ""
-			}
-		}
+// canRevealAuthors reports whether the user making the request may see who
+// wrote anonymous posts
+func canRevealAuthors(c *gin.Context) bool {
+	return middleware.HasPermission(c, models.PermAuthorsReveal)
+}
+
+// hideAnonAuthors hides sensitive info on anonymous confessions from users
+// who can't reveal authors
+func hideAnonAuthors(c *gin.Context, confessions []models.Confession) {
+	if canRevealAuthors(c) {
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"confessions": confessions,
-	})
+	for i := range confessions {
+		hideAnonAuthor(&confessions[i])
+	}
+}
+
+func hideAnonAuthor(confession *models.Confession) {
+	if confession.Anon {
+		confession.UserID = nil
+		confession.GuestUUID = nil
+		confession.Username = ""
+	}
 }
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/middleware"
 	"errors"
 	"fmt"
 	"net/http"
@@ -14,8 +15,16 @@ func HandleError(c *gin.Context, err error) {
 		return
 	}
 
+	// 403 Forbidden with the details of the ban
+	var banErr *errs.BanError
+	if errors.As(err, &banErr) {
+		middleware.AbortWithBan(c, banErr)
+		return
+	}
+
 	// 404 Not Found
 	if errors.Is(err, errs.ErrConfessionNotFound) ||
+		errors.Is(err, errs.ErrCommentNotFound) ||
 		errors.Is(err, errs.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": err.Error(),
@@ -32,7 +41,52 @@ func HandleError(c *gin.Context, err error) {
 		errors.Is(err, errs.ErrReportExists) ||
 		errors.Is(err, errs.ErrUserAlreadyBanned) ||
 		errors.Is(err, errs.ErrUserNotBanned) ||
-		errors.Is(err, errs.ErrYouCannotBanYourself) {
+		errors.Is(err, errs.ErrYouCannotBanYourself) ||
+		errors.Is(err, errs.ErrInvalidCursor) ||
+		errors.Is(err, errs.ErrInvalidLimit) ||
+		errors.Is(err, errs.ErrInvalidSort) ||
+		errors.Is(err, errs.ErrInvalidReactionKind) ||
+		errors.Is(err, errs.ErrCommentTextEmpty) ||
+		errors.Is(err, errs.ErrCommentTooLong) ||
+		errors.Is(err, errs.ErrCommentTooDeep) ||
+		errors.Is(err, errs.ErrCommentParentMismatch) ||
+		errors.Is(err, errs.ErrInvalidReportStatus) ||
+		errors.Is(err, errs.ErrInvalidReportTransition) ||
+		errors.Is(err, errs.ErrInvalidResolutionAction) ||
+		errors.Is(err, errs.ErrActionRequiresResolve) ||
+		errors.Is(err, errs.ErrActionTargetMismatch) ||
+		errors.Is(err, errs.ErrReportedContentGone) ||
+		errors.Is(err, errs.ErrInvalidAuditFilter) ||
+		errors.Is(err, errs.ErrInvalidBanScope) ||
+		errors.Is(err, errs.ErrBanExpiryInPast) ||
+		errors.Is(err, errs.ErrNoGuestToClaim) ||
+		errors.Is(err, errs.ErrGuestAlreadyClaimed) ||
+		errors.Is(err, errs.ErrChallengeRequired) ||
+		errors.Is(err, errs.ErrChallengeInvalid) ||
+		errors.Is(err, errs.ErrChallengeExpired) ||
+		errors.Is(err, errs.ErrChallengeUnsolved) ||
+		errors.Is(err, errs.ErrChallengeUsed) ||
+		errors.Is(err, errs.ErrInvalidConfessionStatus) ||
+		errors.Is(err, errs.ErrInvalidModerationTransition) ||
+		errors.Is(err, errs.ErrContentRejected) ||
+		errors.Is(err, errs.ErrDuplicateConfession) ||
+		errors.Is(err, errs.ErrInvalidBulkAction) ||
+		errors.Is(err, errs.ErrInvalidBulkOperation) ||
+		errors.Is(err, errs.ErrBulkTooLarge) ||
+		errors.Is(err, errs.ErrInvalidFilterPattern) ||
+		errors.Is(err, errs.ErrInvalidFilterKind) ||
+		errors.Is(err, errs.ErrInvalidFilterAction) ||
+		errors.Is(err, errs.ErrInvalidRole) ||
+		errors.Is(err, errs.ErrLastOwner) ||
+		errors.Is(err, errs.ErrInvalidEmailToken) ||
+		errors.Is(err, errs.ErrEmailAlreadyVerified) ||
+		errors.Is(err, errs.ErrTwoFactorAlreadyEnabled) ||
+		errors.Is(err, errs.ErrTwoFactorNotEnabled) ||
+		errors.Is(err, errs.ErrTwoFactorSetupMissing) ||
+		errors.Is(err, errs.ErrWeakPassword) ||
+		errors.Is(err, errs.ErrEmptyUsernameOrEmail) ||
+		errors.Is(err, errs.ErrInvalidLockoutScope) ||
+		errors.Is(err, errs.ErrSamePassword) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
@@ -41,7 +95,13 @@ func HandleError(c *gin.Context, err error) {
 
 	// 401 Unauthorized
 	if errors.Is(err, errs.ErrUnauthorized) ||
-		errors.Is(err, errs.ErrIncorrectUsernameOrPassword) {
+		errors.Is(err, errs.ErrIncorrectUsernameOrPassword) ||
+		errors.Is(err, errs.ErrInvalidRefreshToken) ||
+		errors.Is(err, errs.ErrRefreshTokenReused) ||
+		errors.Is(err, errs.ErrTokenRevoked) ||
+		errors.Is(err, errs.ErrInvalidTwoFactorCode) ||
+		errors.Is(err, errs.ErrInvalidLoginChallenge) ||
+		errors.Is(err, errs.ErrInvalidBootstrapToken) {
 		c.JSON(http.StatusUnauthorized, gin.H{	
 			"error": err.Error(),
 		})
@@ -54,8 +114,12 @@ func HandleError(c *gin.Context, err error) {
 		errors.Is(err, errs.ErrIncorrectUsernameOrPassword) ||
 		errors.Is(err, errs.ErrForbidden) ||
 		errors.Is(err, errs.ErrForbiddenDelete) ||
+		errors.Is(err, errs.ErrForbiddenComment) ||
+		errors.Is(err, errs.ErrGuestEditWindowClosed) ||
 		errors.Is(err, errs.ErrUserBanned) ||
-		errors.Is(err, errs.ErrYouCannotBanOtherAdmin) {
+		errors.Is(err, errs.ErrOutranked) ||
+		errors.Is(err, errs.ErrTwoFactorRequired) ||
+		errors.Is(err, errs.ErrPasswordChangeRequired) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": err.Error(),
 		})
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetContentFilters godoc
+// @Summary Список правил фильтра контента (только для администраторов)
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string][]models.ContentFilter
+// @Failure 500 {object} map[string]string
+// @Router /admin/filters [get]
+func GetContentFilters(c *gin.Context) {
+	filters, err := service.GetContentFilters()
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"filters": filters,
+	})
+}
+
+// CreateContentFilter godoc
+// @Summary Создание правила фильтра контента (только для администраторов)
+// @Description A literal matches a whole word after normalization: case, accents, invisible characters,
+// @Description Cyrillic and Greek look-alikes and leetspeak don't matter. A regex matches the lowercased text.
+// @Description Kind defaults to literal, action to reject.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param filter body models.ContentFilterRequest true "Rule"
+// @Success 201 {object} map[string]models.ContentFilter
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/filters [post]
+func CreateContentFilter(c *gin.Context) {
+	var req models.ContentFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	filter, err := service.CreateContentFilter(req, getActor(c))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"filter": filter,
+	})
+}
+
+// UpdateContentFilter godoc
+// @Summary Изменение правила фильтра контента (только для администраторов)
+// @Description Only the fields present in the body change.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Filter ID"
+// @Param filter body models.ContentFilterRequest true "Fields to change"
+// @Success 200 {object} map[string]models.ContentFilter
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/filters/{id} [put]
+func UpdateContentFilter(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		HandleError(c, errs.ErrInvalidId)
+		return
+	}
+
+	var req models.ContentFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	filter, err := service.UpdateContentFilter(id, req, getActor(c))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"filter": filter,
+	})
+}
+
+// DeleteContentFilter godoc
+// @Summary Удаление правила фильтра контента (только для администраторов)
+// @Tags admin
+// @Param id path int true "Filter ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/filters/{id} [delete]
+func DeleteContentFilter(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		HandleError(c, errs.ErrInvalidId)
+		return
+	}
+
+	if err := service.DeleteContentFilter(id, getActor(c)); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Filter deleted successfully",
+	})
+}
@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"github.com/hadisjane/confessly/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getIdentity returns who is making the request: a registered user or a
+// guest. Exactly one of the two is set for requests on public routes.
+func getIdentity(c *gin.Context) (*int, *string) {
+	if userID := c.GetInt(middleware.UserIDCtx); userID != 0 {
+		return &userID, nil
+	}
+
+	if guestUUID := c.GetString(middleware.GuestUUIDCtx); guestUUID != "" {
+		return nil, &guestUUID
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLoginLockouts godoc
+// @Summary Аккаунты и IP, которым сейчас запрещён вход (только для администраторов)
+// @Description Lists the failed login records that refuse logins right now: a short backoff after a failure,
+// @Description or a lockout once locked_at is set. Account records are keyed by the username and carry its
+// @Description user_id; failures with usernames that have no account all count under the empty key.
+// @Tags admin
+// @Produce json
+// @Param scope query string false "Only this scope" Enums(account, ip)
+// @Param limit query int false "Page size (capped by the server)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort order" Enums(new, old)
+// @Success 200 {object} models.LoginFailurePage
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/lockouts [get]
+func GetLoginLockouts(c *gin.Context) {
+	params, err := getPageParams(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	page, err := service.GetLoginLockouts(c.Query("scope"), params)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// ClearLoginLockout godoc
+// @Summary Снятие блокировки входа (только для администраторов)
+// @Description Forgets the failed logins of the account or IP, so it can log in again right away.
+// @Tags admin
+// @Param id path int true "Lockout ID"
+// @Param body body models.ModerationReason false "Reason for the audit log"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/lockouts/{id} [delete]
+func ClearLoginLockout(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		HandleError(c, errs.ErrInvalidId)
+		return
+	}
+
+	reason, err := getModerationReason(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := service.ClearLoginLockout(id, getActor(c), reason); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Lockout cleared successfully",
+	})
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetModerationQueue godoc
+// @Summary Очередь конфесий на модерации (для модераторов и администраторов)
+// @Description Pending confessions, oldest first by default.
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Page size (capped by the server)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort order" Enums(old, new)
+// @Success 200 {object} models.ConfessionPage
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/moderation/queue [get]
+func GetModerationQueue(c *gin.Context) {
+	params, err := getPageParams(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	page, err := service.GetModerationQueue(params)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetAutoHiddenConfessions godoc
+// @Summary Конфесии, скрытые по жалобам (для модераторов и администраторов)
+// @Description Confessions hidden automatically after enough reports, oldest first by default.
+// @Description Approve one to publish it again or resolve its reports to keep it hidden.
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Page size (capped by the server)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort order" Enums(old, new)
+// @Success 200 {object} models.ConfessionPage
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/moderation/auto-hidden [get]
+func GetAutoHiddenConfessions(c *gin.Context) {
+	params, err := getPageParams(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	page, err := service.GetAutoHiddenConfessions(params)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// ApproveConfession godoc
+// @Summary Одобрение конфесии (для модераторов и администраторов)
+// @Description Publishes a pending confession. Rejected and hidden confessions can be published too.
+// @Tags admin
+// @Param id path int true "Confession ID"
+// @Param body body models.ModerationReason false "Reason for the audit log"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/confessions/{id}/approve [post]
+func ApproveConfession(c *gin.Context) {
+	confessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || confessionID <= 0 {
+		HandleError(c, errs.ErrInvalidId)
+		return
+	}
+
+	reason, err := getModerationReason(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := service.ApproveConfession(confessionID, getActor(c), reason); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Confession approved successfully",
+	})
+}
+
+// RejectConfession godoc
+// @Summary Отклонение конфесии (для модераторов и администраторов)
+// @Description Rejects a pending confession. The reason is shown to the author.
+// @Tags admin
+// @Param id path int true "Confession ID"
+// @Param body body models.ModerationReason false "Reason shown to the author and written to the audit log"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/confessions/{id}/reject [post]
+func RejectConfession(c *gin.Context) {
+	confessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || confessionID <= 0 {
+		HandleError(c, errs.ErrInvalidId)
+		return
+	}
+
+	reason, err := getModerationReason(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := service.RejectConfession(confessionID, getActor(c), reason); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Confession rejected successfully",
+	})
+}
+
+// GetSimilarConfessions godoc
+// @Summary Похожие конфесии и их авторы (для модераторов и администраторов)
+// @Description Near-duplicates of a confession from any time, closest first, with distance in differing fingerprint bits.
+// @Description authors lists everyone who posted the confession or one of its near-duplicates, so a whole campaign can be banned at once.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Confession ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/confessions/{id}/similar [get]
+func GetSimilarConfessions(c *gin.Context) {
+	confessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || confessionID <= 0 {
+		HandleError(c, errs.ErrInvalidId)
+		return
+	}
+
+	similar, authors, err := service.GetSimilarConfessions(confessionID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"confessions": similar,
+		"authors":     authors,
+	})
+}
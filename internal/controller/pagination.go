@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getPageParams reads the limit, cursor and sort query parameters
+func getPageParams(c *gin.Context) (models.PageParams, error) {
+	params := models.PageParams{
+		Cursor: c.Query("cursor"),
+		Sort:   c.Query("sort"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return models.PageParams{}, errs.ErrInvalidLimit
+		}
+		params.Limit = n
+	}
+
+	return params, nil
+}
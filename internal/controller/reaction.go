@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/middleware"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddReaction godoc
+// @Summary Добавление реакции на конфесию
+// @Description Each user or guest can leave one reaction of each kind.
+// @Tags reaction
+// @Produce json
+// @Param id path int true "Confession ID"
+// @Param kind path string true "Reaction kind" Enums(hug, same, support, wow)
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /confessions/{id}/reactions/{kind} [put]
+func AddReaction(c *gin.Context) {
+	reaction, err := getReaction(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := service.AddReaction(reaction); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reaction added successfully",
+	})
+}
+
+// RemoveReaction godoc
+// @Summary Удаление реакции с конфесии
+// @Tags reaction
+// @Produce json
+// @Param id path int true "Confession ID"
+// @Param kind path string true "Reaction kind" Enums(hug, same, support, wow)
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /confessions/{id}/reactions/{kind} [delete]
+func RemoveReaction(c *gin.Context) {
+	reaction, err := getReaction(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := service.RemoveReaction(reaction); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reaction removed successfully",
+	})
+}
+
+// GetReactions godoc
+// @Summary Получение реакций на конфесию
+// @Description Reactors are only listed for confessions that are not anonymous.
+// @Tags reaction
+// @Produce json
+// @Param id path int true "Confession ID"
+// @Success 200 {object} models.ReactionSummary
+// @Failure 404 {object} map[string]string
+// @Router /confessions/{id}/reactions [get]
+func GetReactions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		HandleError(c, errs.ErrInvalidId)
+		return
+	}
+
+	confession, err := service.GetConfession(id)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	userID, guestUUID := getIdentity(c)
+	if !service.CanViewConfession(confession, userID, guestUUID, middleware.HasPermission(c, models.PermConfessionsModerate)) {
+		HandleError(c, errs.ErrConfessionNotFound)
+		return
+	}
+
+	// Same anonymity rule as for the confession author
+	reveal := canRevealAuthors(c)
+	withReactors := !confession.Anon || reveal
+
+	summary, err := service.GetReactionSummary(id, userID, guestUUID, withReactors)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if !reveal {
+		for i := range summary.Reactors {
+			summary.Reactors[i].GuestUUID = nil
+		}
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+func getReaction(c *gin.Context) (models.Reaction, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return models.Reaction{}, errs.ErrInvalidId
+	}
+
+	userID, guestUUID := getIdentity(c)
+	if userID == nil && guestUUID == nil {
+		return models.Reaction{}, errs.ErrUnauthorized
+	}
+
+	return models.Reaction{
+		ConfessionID: id,
+		Kind:         c.Param("kind"),
+		UserID:       userID,
+		GuestUUID:    guestUUID,
+	}, nil
+}
@@ -20,6 +20,7 @@ import (
 // @Success 201 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /report [post]
 func CreateReport(c *gin.Context) {
 	// Get user ID from context
@@ -37,8 +38,8 @@ func CreateReport(c *gin.Context) {
 	}
 
 	// Validate required fields
-	if report.ConfessionID <= 0 {
-		HandleError(c, fmt.Errorf("confession_id is required and must be greater than 0"))
+	if (report.ConfessionID == nil || *report.ConfessionID <= 0) && (report.CommentID == nil || *report.CommentID <= 0) {
+		HandleError(c, fmt.Errorf("confession_id or comment_id is required and must be greater than 0"))
 		return
 	}
 
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRoles godoc
+// @Summary Роли и их права (только для администраторов)
+// @Description Roles from least to most privileged with the permissions each one grants.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/roles [get]
+func GetRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"roles":       models.Roles,
+		"permissions": models.RolePermissions,
+	})
+}
+
+// SetUserRole godoc
+// @Summary Изменение роли пользователя (только для администраторов)
+// @Description Admins can move users between user and moderator. Only an owner can grant or take away
+// @Description admin and owner, and the last owner can't be demoted. The user's current access tokens
+// @Description stop working, the next refresh issues one with the new role.
+// @Tags admin
+// @Accept json
+// @Param id path int true "User ID"
+// @Param body body models.RoleRequest true "New role and reason for the audit log"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/role [put]
+func SetUserRole(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || userID <= 0 {
+		HandleError(c, errs.ErrInvalidId)
+		return
+	}
+
+	var req models.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := service.SetUserRole(userID, req, getActor(c)); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User role changed successfully",
+	})
+}
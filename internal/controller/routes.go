@@ -3,6 +3,7 @@ package controller
 import (
 	"github.com/hadisjane/confessly/internal/configs"
 	"github.com/hadisjane/confessly/internal/middleware"
+	"github.com/hadisjane/confessly/internal/models"
 	"github.com/hadisjane/confessly/logger"
 	"fmt"
 
@@ -21,6 +22,12 @@ func RunServer() error {
 
 	r := gin.Default()
 
+	// Forwarded client addresses are only believed from the configured
+	// proxies, otherwise anyone could pick the IP that rate limits count by
+	if err := r.SetTrustedProxies(configs.AppSettings.AppParams.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
 	// Add logging middleware
 	r.Use(gin.LoggerWithWriter(logger.Info.Writer()))
 	r.Use(gin.Recovery())
@@ -31,8 +38,23 @@ func RunServer() error {
 	// Auth routes
 	authG := r.Group("/auth")
 	{
-		authG.POST("/register", Register)
-		authG.POST("/login", Login)
+		authG.POST("/register", middleware.RateLimit(models.RateLimitRegister), Register)
+		authG.POST("/login", middleware.RateLimit(models.RateLimitLogin), Login)
+		authG.POST("/login/2fa", middleware.RateLimit(models.RateLimitLogin), LoginTwoFactor)
+		authG.POST("/refresh", Refresh)
+		authG.POST("/logout", middleware.CheckUserAuthentication, Logout)
+		authG.POST("/logout-all", middleware.CheckUserAuthentication, LogoutAll)
+		authG.POST("/email/verify", VerifyEmail)
+		authG.POST("/email/resend", middleware.CheckUserAuthentication, middleware.RateLimit(models.RateLimitResendVerify), ResendVerificationEmail)
+		authG.POST("/password/forgot", middleware.RateLimit(models.RateLimitForgotPassword), ForgotPassword)
+		authG.POST("/password/reset", ResetPassword)
+		authG.POST("/password/change", middleware.RateLimit(models.RateLimitLogin), ChangePassword)
+		authG.POST("/bootstrap", middleware.RateLimit(models.RateLimitLogin), Bootstrap)
+		authG.GET("/2fa", middleware.CheckUserAuthentication, GetTwoFactorStatus)
+		authG.POST("/2fa/setup", middleware.CheckUserAuthentication, SetupTwoFactor)
+		authG.POST("/2fa/confirm", middleware.CheckUserAuthentication, ConfirmTwoFactor)
+		authG.POST("/2fa/disable", middleware.CheckUserAuthentication, DisableTwoFactor)
+		authG.POST("/2fa/recovery-codes", middleware.CheckUserAuthentication, RegenerateRecoveryCodes)
 	}
 
 	// Public routes (no auth required)
@@ -43,7 +65,18 @@ func RunServer() error {
 		public.GET("/confessions", GetAllConfessions)
 		public.GET("/confessions/:id", GetConfession)
 		public.GET("/confessions/search", SearchConfessions)
-		public.POST("/confessions", CreateConfession)
+		public.GET("/confessions/mine", GetMyConfessions)
+		public.GET("/challenge", GetChallenge)
+		public.POST("/confessions", middleware.CheckPostingBan, middleware.RateLimit(models.RateLimitCreateConfession), CreateConfession)
+		public.PUT("/confessions/:id", middleware.CheckPostingBan, UpdateConfession)
+		public.DELETE("/confessions/:id", DeleteConfession)
+		public.GET("/confessions/:id/reactions", GetReactions)
+		public.PUT("/confessions/:id/reactions/:kind", AddReaction)
+		public.DELETE("/confessions/:id/reactions/:kind", RemoveReaction)
+		public.GET("/confessions/:id/comments", GetComments)
+		public.POST("/confessions/:id/comments", middleware.CheckPostingBan, middleware.RateLimit(models.RateLimitCreateComment), CreateComment)
+		public.PUT("/comments/:id", middleware.CheckPostingBan, UpdateComment)
+		public.DELETE("/comments/:id", DeleteComment)
 	}
 
 	// API routes with authentication middleware
@@ -52,7 +85,7 @@ func RunServer() error {
 	// Confession routes
 	confessionsG := apiG.Group("/confessions")
 	{
-		confessionsG.PUT("/:id", UpdateConfession)
+		confessionsG.PUT("/:id", middleware.CheckPostingBan, UpdateConfession)
 		confessionsG.DELETE("/:id", DeleteConfession)
 		confessionsG.GET("/search", SearchConfessions)
 	}
@@ -60,24 +93,43 @@ func RunServer() error {
 	// Report routes
 	reportsG := apiG.Group("/reports")
 	{
-		reportsG.POST("", CreateReport)
+		reportsG.POST("", middleware.RateLimit(models.RateLimitCreateReport), CreateReport)
 	}
 
-	// Admin routes
-	adminG := apiG.Group("/admin", middleware.CheckAdminAuthentication)
+	// Admin routes, each guarded by the permission it needs
+	adminG := apiG.Group("/admin", middleware.RequireTwoFactor)
 	{
-		adminG.GET("/reports", GetReports)
-		adminG.PUT("/reports/:id", UpdateReport)
-		adminG.GET("/reports/:id", GetReport)
-		adminG.GET("/users", GetUsers)
-		adminG.GET("/users/:id", GetUserByID)
-		adminG.DELETE("/confessions/:id", DeleteConfessionByAdmin)
-		adminG.POST("/users/:id/ban", BanUser)
-		adminG.POST("/users/:id/unban", UnbanUser)
-		adminG.GET("/guests", GetGuestUsers)
-		adminG.GET("/guests/:uuid", GetGuestUser)
-		adminG.POST("/guests/:uuid/ban", BanGuestUser)
-		adminG.POST("/guests/:uuid/unban", UnbanGuestUser)
+		can := middleware.RequirePermission
+		adminG.GET("/reports", can(models.PermReportsView), GetReports)
+		adminG.PUT("/reports/:id", can(models.PermReportsResolve), UpdateReport)
+		adminG.GET("/reports/:id", can(models.PermReportsView), GetReport)
+		adminG.GET("/users", can(models.PermUsersView), GetUsers)
+		adminG.GET("/users/:id", can(models.PermUsersView), GetUserByID)
+		adminG.PUT("/users/:id/role", can(models.PermRolesAssign), SetUserRole)
+		adminG.GET("/roles", can(models.PermRolesAssign), GetRoles)
+		adminG.DELETE("/confessions/:id", can(models.PermConfessionsDelete), DeleteConfessionByAdmin)
+		adminG.GET("/moderation/queue", can(models.PermConfessionsModerate), GetModerationQueue)
+		adminG.GET("/moderation/auto-hidden", can(models.PermConfessionsModerate), GetAutoHiddenConfessions)
+		adminG.POST("/confessions/:id/approve", can(models.PermConfessionsModerate), ApproveConfession)
+		adminG.POST("/confessions/:id/reject", can(models.PermConfessionsModerate), RejectConfession)
+		adminG.GET("/confessions/:id/similar", can(models.PermConfessionsModerate), GetSimilarConfessions)
+		adminG.DELETE("/comments/:id", can(models.PermCommentsRemove), RemoveCommentByAdmin)
+		adminG.POST("/users/:id/ban", can(models.PermUsersBan), BanUser)
+		adminG.POST("/users/:id/unban", can(models.PermUsersBan), UnbanUser)
+		adminG.GET("/users/:id/bans", can(models.PermUsersView), GetUserBans)
+		adminG.GET("/guests", can(models.PermUsersView), GetGuestUsers)
+		adminG.GET("/guests/:uuid", can(models.PermUsersView), GetGuestUser)
+		adminG.POST("/guests/:uuid/ban", can(models.PermUsersBan), BanGuestUser)
+		adminG.POST("/guests/:uuid/unban", can(models.PermUsersBan), UnbanGuestUser)
+		adminG.GET("/guests/:uuid/bans", can(models.PermUsersView), GetGuestBans)
+		adminG.GET("/audit", can(models.PermAuditView), GetAuditLog)
+		adminG.POST("/bulk", can(models.PermBulk), RunBulk)
+		adminG.GET("/filters", can(models.PermFiltersManage), GetContentFilters)
+		adminG.POST("/filters", can(models.PermFiltersManage), CreateContentFilter)
+		adminG.PUT("/filters/:id", can(models.PermFiltersManage), UpdateContentFilter)
+		adminG.DELETE("/filters/:id", can(models.PermFiltersManage), DeleteContentFilter)
+		adminG.GET("/lockouts", can(models.PermLockoutsManage), GetLoginLockouts)
+		adminG.DELETE("/lockouts/:id", can(models.PermLockoutsManage), ClearLoginLockout)
 	}
 
 	// Get server address from config
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/middleware"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTwoFactorStatus godoc
+// @Summary Состояние двухфакторной аутентификации
+// @Tags auth
+// @Produce json
+// @Success 200 {object} models.TwoFactorStatus
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/2fa [get]
+func GetTwoFactorStatus(c *gin.Context) {
+	userID := c.GetInt(middleware.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	status, err := service.GetTwoFactorStatus(userID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// SetupTwoFactor godoc
+// @Summary Начало подключения двухфакторной аутентификации
+// @Description Returns a new TOTP secret and its otpauth:// URL for the authenticator app. They are shown only once.
+// @Description 2FA turns on after the secret is confirmed at /auth/2fa/confirm; until then a new setup replaces it.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} models.TwoFactorSetup
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/2fa/setup [post]
+func SetupTwoFactor(c *gin.Context) {
+	userID := c.GetInt(middleware.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	setup, err := service.SetupTwoFactor(userID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, setup)
+}
+
+// ConfirmTwoFactor godoc
+// @Summary Подтверждение двухфакторной аутентификации кодом
+// @Description Turns 2FA on with a code from the authenticator app and returns 10 one-time recovery codes.
+// @Description They are shown only once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body models.TwoFactorCodeRequest true "Code from the authenticator app"
+// @Success 200 {object} models.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/2fa/confirm [post]
+func ConfirmTwoFactor(c *gin.Context) {
+	userID := c.GetInt(middleware.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	codes, err := service.ConfirmTwoFactor(userID, req.Code)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor godoc
+// @Summary Отключение двухфакторной аутентификации
+// @Description Takes a code from the authenticator app or a recovery code.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body models.TwoFactorCodeRequest true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/2fa/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+	userID := c.GetInt(middleware.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	if err := service.DisableTwoFactor(userID, req.Code); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Новые коды восстановления
+// @Description Replaces every recovery code with 10 new ones. Takes a code from the authenticator app or a recovery code.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body models.TwoFactorCodeRequest true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} models.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	userID := c.GetInt(middleware.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	codes, err := service.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
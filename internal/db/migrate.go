@@ -0,0 +1,422 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the pg_advisory_lock key that keeps two replicas
+// from migrating the same database at once
+const migrationLockKey int64 = 0x636f6e6665737331 // "confess1"
+
+// noTxDirective marks a migration that must run outside a transaction,
+// e.g. CREATE INDEX CONCURRENTLY
+const noTxDirective = "-- migrate:no-transaction"
+
+var migrationNameRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+	NoTx     bool
+}
+
+// MigrationStatus describes a migration file together with its state in the database
+type MigrationStatus struct {
+	Migration
+	Applied          bool
+	Dirty            bool
+	ChecksumMismatch bool
+	AppliedAt        *time.Time
+}
+
+type appliedMigration struct {
+	Version   int       `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	Dirty     bool      `db:"dirty"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// LoadMigrations reads the embedded migration files ordered by version
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationNameRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		body, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files with different names", version)
+		}
+
+		if match[3] == "up" {
+			m.Up = string(body)
+			sum := sha256.Sum256(body)
+			m.Checksum = hex.EncodeToString(sum[:])
+			m.NoTx = strings.HasPrefix(m.Up, noTxDirective)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up file", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// MigrateUp applies all pending migrations and returns how many were applied
+func MigrateUp() (int, error) {
+	count := 0
+	err := withMigrationLock(func(conn *sqlx.Conn) error {
+		migrations, applied, err := loadMigrationState(conn)
+		if err != nil {
+			return err
+		}
+
+		if err := checkApplied(migrations, applied); err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+
+			log.Printf("Applying migration %04d_%s...", m.Version, m.Name)
+			if err := applyMigration(conn, m); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// MigrateDown rolls back the given number of most recently applied migrations
+func MigrateDown(steps int) (int, error) {
+	count := 0
+	err := withMigrationLock(func(conn *sqlx.Conn) error {
+		migrations, applied, err := loadMigrationState(conn)
+		if err != nil {
+			return err
+		}
+
+		if err := checkApplied(migrations, applied); err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if strings.TrimSpace(m.Down) == "" {
+				return fmt.Errorf("migration %04d_%s has no down file", m.Version, m.Name)
+			}
+
+			log.Printf("Rolling back migration %04d_%s...", m.Version, m.Name)
+			if err := revertMigration(conn, m); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// MigrationsStatus lists every known migration with its state in the database
+func MigrationsStatus() ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := withMigrationLock(func(conn *sqlx.Conn) error {
+		migrations, applied, err := loadMigrationState(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			status := MigrationStatus{Migration: m}
+			if row, ok := applied[m.Version]; ok {
+				status.Applied = true
+				status.Dirty = row.Dirty
+				status.ChecksumMismatch = row.Checksum != m.Checksum
+				appliedAt := row.AppliedAt
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+
+		// Versions recorded in the database without a file in this build
+		for version, row := range applied {
+			if !hasMigration(migrations, version) {
+				appliedAt := row.AppliedAt
+				statuses = append(statuses, MigrationStatus{
+					Migration: Migration{Version: version, Name: row.Name},
+					Applied:   true,
+					Dirty:     row.Dirty,
+					AppliedAt: &appliedAt,
+				})
+			}
+		}
+		sort.Slice(statuses, func(i, j int) bool {
+			return statuses[i].Version < statuses[j].Version
+		})
+		return nil
+	})
+	return statuses, err
+}
+
+// ForceMigrationVersion records the schema as being exactly at version without
+// running any SQL: migrations up to version are marked applied and clean with
+// their current checksums, later ones are forgotten. Use it after fixing a
+// failed or edited migration by hand.
+func ForceMigrationVersion(version int) error {
+	return withMigrationLock(func(conn *sqlx.Conn) error {
+		migrations, _, err := loadMigrationState(conn)
+		if err != nil {
+			return err
+		}
+
+		if version != 0 && !hasMigration(migrations, version) {
+			return fmt.Errorf("unknown migration version %d", version)
+		}
+
+		ctx := context.Background()
+		tx, err := conn.BeginTxx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+
+		if _, err := tx.Exec("DELETE FROM schema_migrations WHERE version > $1", version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to reset migrations: %w", err)
+		}
+
+		for _, m := range migrations {
+			if m.Version > version {
+				break
+			}
+			_, err := tx.Exec(`
+				INSERT INTO schema_migrations (version, name, checksum, dirty)
+				VALUES ($1, $2, $3, FALSE)
+				ON CONFLICT (version) DO UPDATE
+				SET name = EXCLUDED.name, checksum = EXCLUDED.checksum, dirty = FALSE`,
+				m.Version, m.Name, m.Checksum)
+			if err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to force migration %d: %w", m.Version, err)
+			}
+		}
+
+		return tx.Commit()
+	})
+}
+
+// withMigrationLock runs fn on a dedicated connection holding the migration
+// advisory lock. Advisory locks belong to the session, so everything that
+// touches the schema has to go through that same connection.
+// LockMigrationsTx holds the migration lock until tx ends, for startup work
+// that only one replica may do at a time
+func LockMigrationsTx(tx *sqlx.Tx) error {
+	_, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", migrationLockKey)
+	return err
+}
+
+func withMigrationLock(fn func(conn *sqlx.Conn) error) error {
+	if db == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+			log.Printf("Failed to release migration lock: %v", err)
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		dirty BOOLEAN NOT NULL DEFAULT FALSE,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func loadMigrationState(conn *sqlx.Conn) ([]Migration, map[int]appliedMigration, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []appliedMigration
+	err = conn.SelectContext(context.Background(), &rows,
+		"SELECT version, name, checksum, dirty, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	applied := make(map[int]appliedMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+
+	return migrations, applied, nil
+}
+
+// checkApplied refuses to go on while a migration is dirty or an applied
+// migration file has been edited since it ran
+func checkApplied(migrations []Migration, applied map[int]appliedMigration) error {
+	for _, m := range migrations {
+		row, ok := applied[m.Version]
+		if !ok {
+			continue
+		}
+		if row.Dirty {
+			return fmt.Errorf("migration %04d_%s is dirty: fix the schema by hand and run `migrate force`", m.Version, m.Name)
+		}
+		if row.Checksum != m.Checksum {
+			return fmt.Errorf("migration %04d_%s was changed after it was applied (checksum mismatch)", m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+func applyMigration(conn *sqlx.Conn, m Migration) error {
+	ctx := context.Background()
+
+	if m.NoTx {
+		_, err := conn.ExecContext(ctx,
+			"INSERT INTO schema_migrations (version, name, checksum, dirty) VALUES ($1, $2, $3, TRUE)",
+			m.Version, m.Name, m.Checksum)
+		if err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+		}
+		if _, err := conn.ExecContext(ctx, m.Up); err != nil {
+			return fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		_, err = conn.ExecContext(ctx, "UPDATE schema_migrations SET dirty = FALSE WHERE version = $1", m.Version)
+		return err
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if _, err := tx.Exec(m.Up); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+	}
+
+	_, err = tx.Exec("INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+		m.Version, m.Name, m.Checksum)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+
+	return tx.Commit()
+}
+
+func revertMigration(conn *sqlx.Conn, m Migration) error {
+	ctx := context.Background()
+
+	if m.NoTx {
+		_, err := conn.ExecContext(ctx, "UPDATE schema_migrations SET dirty = TRUE WHERE version = $1", m.Version)
+		if err != nil {
+			return fmt.Errorf("failed to mark migration %d: %w", m.Version, err)
+		}
+		if _, err := conn.ExecContext(ctx, m.Down); err != nil {
+			return fmt.Errorf("rollback of %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		_, err = conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", m.Version)
+		return err
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if _, err := tx.Exec(m.Down); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("rollback of %04d_%s failed: %w", m.Version, m.Name, err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM schema_migrations WHERE version = $1", m.Version); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to remove migration %d: %w", m.Version, err)
+	}
+
+	return tx.Commit()
+}
+
+func hasMigration(migrations []Migration, version int) bool {
+	for _, m := range migrations {
+		if m.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS reports;
+DROP TABLE IF EXISTS confessions;
+DROP TABLE IF EXISTS guest_users;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL PRIMARY KEY,
+	username VARCHAR(255) NOT NULL UNIQUE,
+	email VARCHAR(255) NOT NULL UNIQUE,
+	role VARCHAR(255) NOT NULL DEFAULT 'user',
+	password VARCHAR(255) NOT NULL,
+	banned BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS guest_users (
+	uuid UUID PRIMARY KEY,
+	banned BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS confessions (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	guest_uuid UUID REFERENCES guest_users(uuid) ON DELETE SET NULL,
+	username VARCHAR(255) NOT NULL,
+	title VARCHAR(100) NOT NULL DEFAULT 'Untitled',
+	text TEXT NOT NULL,
+	anon BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT NULL,
+
+	-- Ensure either user_id or guest_uuid is set
+	CONSTRAINT chk_user_or_guest CHECK (
+		(user_id IS NOT NULL AND guest_uuid IS NULL) OR
+		(user_id IS NULL AND guest_uuid IS NOT NULL)
+	)
+);
+
+CREATE TABLE IF NOT EXISTS reports (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER REFERENCES users(id) NOT NULL,
+	confession_id INTEGER REFERENCES confessions(id) NOT NULL,
+	reason TEXT NOT NULL,
+	status VARCHAR(255) NOT NULL DEFAULT 'pending',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT NULL
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_confessions_created_at_id;
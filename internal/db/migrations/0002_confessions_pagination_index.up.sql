@@ -0,0 +1,3 @@
+-- Index for keyset pagination of confession listings
+CREATE INDEX IF NOT EXISTS idx_confessions_created_at_id
+	ON confessions (created_at DESC, id DESC);
@@ -0,0 +1,20 @@
+CREATE TABLE reactions (
+	id SERIAL PRIMARY KEY,
+	confession_id INTEGER NOT NULL REFERENCES confessions(id) ON DELETE CASCADE,
+	kind VARCHAR(32) NOT NULL,
+	user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+	guest_uuid UUID REFERENCES guest_users(uuid) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	CONSTRAINT chk_reaction_kind CHECK (kind IN ('hug', 'same', 'support', 'wow')),
+
+	-- Ensure either user_id or guest_uuid is set
+	CONSTRAINT chk_reaction_user_or_guest CHECK (
+		(user_id IS NOT NULL AND guest_uuid IS NULL) OR
+		(user_id IS NULL AND guest_uuid IS NOT NULL)
+	)
+);
+
+-- One reaction of each kind per identity
+CREATE UNIQUE INDEX uq_reactions_user ON reactions (confession_id, kind, user_id) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX uq_reactions_guest ON reactions (confession_id, kind, guest_uuid) WHERE guest_uuid IS NOT NULL;
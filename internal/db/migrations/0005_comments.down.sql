@@ -0,0 +1,3 @@
+ALTER TABLE reports DROP COLUMN IF EXISTS comment_id;
+DROP TABLE IF EXISTS comment_aliases;
+DROP TABLE IF EXISTS comments;
@@ -0,0 +1,41 @@
+CREATE TABLE comments (
+	id SERIAL PRIMARY KEY,
+	confession_id INTEGER NOT NULL REFERENCES confessions(id) ON DELETE CASCADE,
+	parent_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+	depth INTEGER NOT NULL DEFAULT 0,
+	user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	guest_uuid UUID REFERENCES guest_users(uuid) ON DELETE SET NULL,
+	username VARCHAR(255) NOT NULL,
+	anon BOOLEAN NOT NULL DEFAULT FALSE,
+	alias VARCHAR(64) DEFAULT NULL,
+	text TEXT NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'visible',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT NULL,
+
+	CONSTRAINT chk_comment_status CHECK (status IN ('visible', 'deleted', 'removed')),
+
+	-- Ensure either user_id or guest_uuid is set
+	CONSTRAINT chk_comment_user_or_guest CHECK (
+		(user_id IS NOT NULL AND guest_uuid IS NULL) OR
+		(user_id IS NULL AND guest_uuid IS NOT NULL)
+	)
+);
+
+CREATE INDEX idx_comments_confession_id ON comments (confession_id, created_at);
+
+-- Anonymous pseudonyms, one per commenter per thread.
+-- identity is "user:<id>" or "guest:<uuid>".
+CREATE TABLE comment_aliases (
+	confession_id INTEGER NOT NULL REFERENCES confessions(id) ON DELETE CASCADE,
+	identity VARCHAR(64) NOT NULL,
+	number INTEGER NOT NULL,
+	alias VARCHAR(64) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	PRIMARY KEY (confession_id, identity),
+	UNIQUE (confession_id, number)
+);
+
+-- Reports can point at a comment; confession_id is still set to its confession
+ALTER TABLE reports ADD COLUMN comment_id INTEGER REFERENCES comments(id) ON DELETE CASCADE;
@@ -0,0 +1,26 @@
+ALTER TABLE confessions DROP COLUMN IF EXISTS hidden;
+
+DROP INDEX IF EXISTS idx_reports_status;
+
+DELETE FROM reports WHERE confession_id IS NULL;
+ALTER TABLE reports
+	DROP CONSTRAINT reports_comment_id_fkey,
+	ADD CONSTRAINT reports_comment_id_fkey
+		FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE;
+ALTER TABLE reports
+	DROP CONSTRAINT reports_confession_id_fkey,
+	ADD CONSTRAINT reports_confession_id_fkey
+		FOREIGN KEY (confession_id) REFERENCES confessions(id);
+ALTER TABLE reports ALTER COLUMN confession_id SET NOT NULL;
+
+ALTER TABLE reports
+	DROP CONSTRAINT IF EXISTS chk_report_action,
+	DROP CONSTRAINT IF EXISTS chk_report_status,
+	DROP COLUMN IF EXISTS resolved_at,
+	DROP COLUMN IF EXISTS note,
+	DROP COLUMN IF EXISTS resolved_by,
+	DROP COLUMN IF EXISTS action;
+
+UPDATE reports SET status = 'approved' WHERE status = 'resolved';
+UPDATE reports SET status = 'rejected' WHERE status = 'dismissed';
+UPDATE reports SET status = 'pending' WHERE status = 'in_review';
@@ -0,0 +1,30 @@
+-- Map the old free-form statuses onto the report lifecycle
+UPDATE reports SET status = 'resolved' WHERE status = 'approved';
+UPDATE reports SET status = 'dismissed' WHERE status = 'rejected';
+UPDATE reports SET status = 'pending' WHERE status NOT IN ('pending', 'in_review', 'resolved', 'dismissed');
+
+ALTER TABLE reports
+	ADD COLUMN action VARCHAR(32) DEFAULT NULL,
+	ADD COLUMN resolved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	ADD COLUMN note TEXT DEFAULT NULL,
+	ADD COLUMN resolved_at TIMESTAMP DEFAULT NULL,
+	ADD CONSTRAINT chk_report_status CHECK (status IN ('pending', 'in_review', 'resolved', 'dismissed')),
+	ADD CONSTRAINT chk_report_action CHECK (action IS NULL OR action IN (
+		'none', 'hide_confession', 'delete_confession', 'ban_author', 'ban_guest'
+	));
+
+-- Resolved reports outlive the content they are about
+ALTER TABLE reports ALTER COLUMN confession_id DROP NOT NULL;
+ALTER TABLE reports
+	DROP CONSTRAINT reports_confession_id_fkey,
+	ADD CONSTRAINT reports_confession_id_fkey
+		FOREIGN KEY (confession_id) REFERENCES confessions(id) ON DELETE SET NULL;
+ALTER TABLE reports
+	DROP CONSTRAINT reports_comment_id_fkey,
+	ADD CONSTRAINT reports_comment_id_fkey
+		FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_reports_status ON reports (status);
+
+-- Confessions hidden by a moderator stay in the database but are not listed
+ALTER TABLE confessions ADD COLUMN hidden BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS moderation_actions;
+DROP FUNCTION IF EXISTS moderation_actions_append_only();
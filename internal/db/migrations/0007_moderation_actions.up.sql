@@ -0,0 +1,32 @@
+-- Append-only audit log of admin actions. Rows reference their actor and
+-- target by value so they survive the deletion of either.
+CREATE TABLE moderation_actions (
+	id SERIAL PRIMARY KEY,
+	actor_id INTEGER NOT NULL,
+	action VARCHAR(64) NOT NULL,
+	target_type VARCHAR(32) NOT NULL,
+	target_id VARCHAR(64) NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	before JSONB NOT NULL DEFAULT 'null',
+	after JSONB NOT NULL DEFAULT 'null',
+	ip VARCHAR(64) NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_moderation_actions_created_at_id ON moderation_actions (created_at DESC, id DESC);
+CREATE INDEX idx_moderation_actions_actor ON moderation_actions (actor_id, created_at DESC);
+CREATE INDEX idx_moderation_actions_target ON moderation_actions (target_type, target_id, created_at DESC);
+
+CREATE FUNCTION moderation_actions_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'moderation_actions is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_moderation_actions_append_only
+	BEFORE UPDATE OR DELETE ON moderation_actions
+	FOR EACH ROW EXECUTE FUNCTION moderation_actions_append_only();
+
+CREATE TRIGGER trg_moderation_actions_no_truncate
+	BEFORE TRUNCATE ON moderation_actions
+	FOR EACH STATEMENT EXECUTE FUNCTION moderation_actions_append_only();
@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN banned BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE guest_users ADD COLUMN banned BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE users SET banned = TRUE WHERE id IN (
+	SELECT user_id FROM bans
+	WHERE scope = 'login' AND lifted_at IS NULL
+	  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP AT TIME ZONE 'UTC')
+);
+UPDATE guest_users SET banned = TRUE WHERE uuid IN (
+	SELECT guest_uuid FROM bans
+	WHERE scope = 'login' AND lifted_at IS NULL
+	  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP AT TIME ZONE 'UTC')
+);
+
+DROP TABLE IF EXISTS bans;
@@ -0,0 +1,35 @@
+-- Bans replace the banned flags. A ban is in force until it expires or is
+-- lifted; login bans lock the account out, posting bans only stop new content.
+CREATE TABLE bans (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+	guest_uuid UUID REFERENCES guest_users(uuid) ON DELETE CASCADE,
+	scope VARCHAR(16) NOT NULL DEFAULT 'login',
+	reason TEXT NOT NULL DEFAULT '',
+	issued_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	expires_at TIMESTAMP DEFAULT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	lifted_at TIMESTAMP DEFAULT NULL,
+	lifted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+
+	CONSTRAINT chk_ban_scope CHECK (scope IN ('login', 'posting')),
+
+	-- Ensure either user_id or guest_uuid is set
+	CONSTRAINT chk_ban_user_or_guest CHECK (
+		(user_id IS NOT NULL AND guest_uuid IS NULL) OR
+		(user_id IS NULL AND guest_uuid IS NOT NULL)
+	)
+);
+
+CREATE INDEX idx_bans_user ON bans (user_id, created_at DESC) WHERE user_id IS NOT NULL;
+CREATE INDEX idx_bans_guest ON bans (guest_uuid, created_at DESC) WHERE guest_uuid IS NOT NULL;
+
+-- Existing bans become permanent login bans
+INSERT INTO bans (user_id, scope, created_at)
+SELECT id, 'login', CURRENT_TIMESTAMP AT TIME ZONE 'UTC' FROM users WHERE banned;
+
+INSERT INTO bans (guest_uuid, scope, created_at)
+SELECT uuid, 'login', CURRENT_TIMESTAMP AT TIME ZONE 'UTC' FROM guest_users WHERE banned;
+
+ALTER TABLE users DROP COLUMN banned;
+ALTER TABLE guest_users DROP COLUMN banned;
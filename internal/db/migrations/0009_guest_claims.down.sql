@@ -0,0 +1,3 @@
+ALTER TABLE guest_users
+	DROP COLUMN IF EXISTS merged_at,
+	DROP COLUMN IF EXISTS merged_into;
@@ -0,0 +1,5 @@
+-- A guest identity can be claimed once by a registered user, who then owns
+-- its confessions. Claimed guests are kept for the history of bans and reports.
+ALTER TABLE guest_users
+	ADD COLUMN merged_into INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	ADD COLUMN merged_at TIMESTAMP DEFAULT NULL;
@@ -0,0 +1 @@
+ALTER TABLE guest_users DROP COLUMN IF EXISTS legacy_cookie;
@@ -0,0 +1,5 @@
+-- Guests created before cookies were signed still send their bare UUID. The
+-- first such request is answered with a signed cookie, after that the bare
+-- UUID is refused so knowing a guest's UUID is no longer enough.
+ALTER TABLE guest_users ADD COLUMN legacy_cookie BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE guest_users ALTER COLUMN legacy_cookie SET DEFAULT FALSE;
@@ -0,0 +1,8 @@
+-- Token buckets of the Postgres rate limit backend, shared by all replicas
+CREATE TABLE rate_limits (
+	key VARCHAR(255) PRIMARY KEY,
+	tokens DOUBLE PRECISION NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_rate_limits_updated_at ON rate_limits (updated_at);
@@ -0,0 +1,8 @@
+-- Nonces of solved proof-of-work challenges. A nonce can only be spent once;
+-- rows are dropped after the challenge expires since it is refused anyway.
+CREATE TABLE used_challenges (
+	nonce VARCHAR(64) PRIMARY KEY,
+	expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_used_challenges_expires_at ON used_challenges (expires_at);
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_confessions_pending;
+
+ALTER TABLE confessions ADD COLUMN hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE confessions SET hidden = TRUE WHERE status <> 'published';
+
+ALTER TABLE confessions
+	DROP CONSTRAINT IF EXISTS chk_confession_status,
+	DROP COLUMN IF EXISTS moderated_at,
+	DROP COLUMN IF EXISTS moderated_by,
+	DROP COLUMN IF EXISTS moderation_reason,
+	DROP COLUMN IF EXISTS status;
@@ -0,0 +1,14 @@
+-- Confessions get a moderation status instead of the hidden flag. Under
+-- pre-moderation new confessions start as pending and wait for an admin.
+ALTER TABLE confessions
+	ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'published',
+	ADD COLUMN moderation_reason TEXT DEFAULT NULL,
+	ADD COLUMN moderated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	ADD COLUMN moderated_at TIMESTAMP DEFAULT NULL,
+	ADD CONSTRAINT chk_confession_status CHECK (status IN ('pending', 'published', 'rejected', 'hidden'));
+
+UPDATE confessions SET status = 'hidden' WHERE hidden;
+
+ALTER TABLE confessions DROP COLUMN hidden;
+
+CREATE INDEX idx_confessions_pending ON confessions (created_at, id) WHERE status = 'pending';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_reports_confession;
+DROP INDEX IF EXISTS idx_confessions_auto_hidden;
+
+ALTER TABLE confessions DROP COLUMN IF EXISTS auto_hidden_at;
@@ -0,0 +1,6 @@
+-- Confessions hidden automatically once enough people report them. They wait
+-- for review and are published again if all their reports are dismissed.
+ALTER TABLE confessions ADD COLUMN auto_hidden_at TIMESTAMP DEFAULT NULL;
+
+CREATE INDEX idx_confessions_auto_hidden ON confessions (created_at, id) WHERE auto_hidden_at IS NOT NULL;
+CREATE INDEX idx_reports_confession ON reports (confession_id) WHERE comment_id IS NULL;
@@ -0,0 +1,13 @@
+-- Blocklist rules checked against confession and report text
+CREATE TABLE content_filters (
+	id SERIAL PRIMARY KEY,
+	pattern TEXT NOT NULL,
+	kind VARCHAR(16) NOT NULL DEFAULT 'literal',
+	action VARCHAR(16) NOT NULL DEFAULT 'reject',
+	enabled BOOLEAN NOT NULL DEFAULT TRUE,
+	created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT NULL,
+	CONSTRAINT chk_content_filter_kind CHECK (kind IN ('literal', 'regex')),
+	CONSTRAINT chk_content_filter_action CHECK (action IN ('reject', 'mask', 'moderate'))
+);
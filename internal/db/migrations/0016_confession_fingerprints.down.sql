@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_confessions_unfingerprinted;
+
+ALTER TABLE confessions DROP COLUMN IF EXISTS fingerprint;
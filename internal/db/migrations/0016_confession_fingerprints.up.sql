@@ -0,0 +1,5 @@
+-- SimHash of the normalized title and text, for finding near-duplicate
+-- confessions. Existing confessions are fingerprinted when the server starts.
+ALTER TABLE confessions ADD COLUMN fingerprint BIGINT DEFAULT NULL;
+
+CREATE INDEX idx_confessions_unfingerprinted ON confessions (id) WHERE fingerprint IS NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_user_role;
+
+UPDATE users SET role = 'admin' WHERE role = 'owner';
+UPDATE users SET role = 'user' WHERE role = 'moderator';
@@ -0,0 +1,10 @@
+-- Moderator and owner roles join user and admin. The oldest admin becomes
+-- the owner, so there is always someone who can manage admins.
+UPDATE users SET role = 'user' WHERE role NOT IN ('user', 'moderator', 'admin', 'owner');
+
+UPDATE users SET role = 'owner'
+WHERE id = (SELECT id FROM users WHERE role = 'admin' ORDER BY id LIMIT 1)
+	AND NOT EXISTS (SELECT 1 FROM users WHERE role = 'owner');
+
+ALTER TABLE users
+	ADD CONSTRAINT chk_user_role CHECK (role IN ('user', 'moderator', 'admin', 'owner'));
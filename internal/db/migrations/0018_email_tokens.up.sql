@@ -0,0 +1,19 @@
+-- Email verification and password reset. Tokens are single-use and only their
+-- SHA-256 hash is stored. email is the address the token was sent to, so a
+-- verification link stops working once the address changes.
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP DEFAULT NULL;
+
+CREATE TABLE email_tokens (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	purpose VARCHAR(20) NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP DEFAULT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT chk_email_token_purpose CHECK (purpose IN ('verify_email', 'reset_password'))
+);
+
+CREATE INDEX idx_email_tokens_user_purpose ON email_tokens (user_id, purpose);
+CREATE INDEX idx_users_email_lower ON users (lower(email));
@@ -0,0 +1,31 @@
+-- TOTP two-factor authentication. totp_enabled_at stays NULL until the user
+-- confirms the secret with a code. totp_last_step is the time step of the
+-- last accepted code, so a code can't be used twice.
+ALTER TABLE users
+	ADD COLUMN totp_secret VARCHAR(64) DEFAULT NULL,
+	ADD COLUMN totp_enabled_at TIMESTAMP DEFAULT NULL,
+	ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- One-time recovery codes, stored as SHA-256 hashes
+CREATE TABLE recovery_codes (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	code_hash CHAR(64) NOT NULL,
+	used_at TIMESTAMP DEFAULT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (user_id, code_hash)
+);
+
+-- Second step of a login with 2FA. The token is handed out after the password
+-- was checked and is exchanged for the session tokens together with a code.
+CREATE TABLE login_challenges (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP DEFAULT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_login_challenges_expires_at ON login_challenges (expires_at);
@@ -0,0 +1,12 @@
+-- Accounts that still have a known password must change it before logging in
+ALTER TABLE users ADD COLUMN password_change_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- One-time token for creating the first owner on a fresh database. Only the
+-- SHA-256 hash is stored, the token itself is printed to the log.
+CREATE TABLE bootstrap_tokens (
+	id SERIAL PRIMARY KEY,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP DEFAULT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,18 @@
+-- Failed logins per account (by the username tried, whether or not it
+-- exists) and per client IP. Until blocked_until the login is refused
+-- without checking the password; locked_at is set once the failures reach
+-- the lockout threshold.
+CREATE TABLE login_failures (
+	id SERIAL PRIMARY KEY,
+	scope VARCHAR(16) NOT NULL,
+	key TEXT NOT NULL,
+	user_id INTEGER DEFAULT NULL REFERENCES users(id) ON DELETE CASCADE,
+	failures INTEGER NOT NULL DEFAULT 0,
+	last_failure_at TIMESTAMP NOT NULL,
+	blocked_until TIMESTAMP NOT NULL,
+	locked_at TIMESTAMP DEFAULT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (scope, key)
+);
+
+CREATE INDEX idx_login_failures_last_failure ON login_failures (last_failure_at);
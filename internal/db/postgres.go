@@ -3,7 +3,6 @@ package db
 import (
 	"github.com/hadisjane/confessly/internal/configs"
 	"fmt"
-	"os"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
@@ -16,12 +15,13 @@ func ConnDB() error {
 	// Build DSN from config
 	cfg := configs.AppSettings.PostgresParams
 	
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host,
 		cfg.Port,
 		cfg.User,
-		os.Getenv("DB_PASSWORD"), // Get password from environment
+		cfg.Password,
 		cfg.Database,
+		cfg.SSLMode,
 	)
 
 	db, err = sqlx.Connect("postgres", dsn)
@@ -30,8 +30,8 @@ func ConnDB() error {
 	}
 
 	// Set connection pool settings
-	db.SetMaxOpenConns(10)
-	db.SetMaxIdleConns(5)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
 
 	return nil
 }
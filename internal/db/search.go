@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/hadisjane/confessly/internal/configs"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// searchLanguageRe guards the language names that end up inlined in DDL and queries
+var searchLanguageRe = regexp.MustCompile(`^[a-z_]+$`)
+
+// SearchLanguages returns the text search configurations used for
+// full-text search over confessions
+func SearchLanguages() []string {
+	return configs.AppSettings.SearchParams.Languages
+}
+
+// SearchVectorExpr returns the expression the search_vector column is generated from.
+// Title matches weigh more than body matches.
+func SearchVectorExpr(languages []string) string {
+	parts := make([]string, 0, len(languages))
+	for _, lang := range languages {
+		parts = append(parts, fmt.Sprintf(
+			`setweight(to_tsvector('%[1]s'::regconfig, coalesce(title, '')), 'A') || `+
+				`setweight(to_tsvector('%[1]s'::regconfig, coalesce(text, '')), 'B')`, lang))
+	}
+	return strings.Join(parts, " || ")
+}
+
+// SearchQueryExpr returns a tsquery expression over the placeholder arg,
+// parsed with websearch syntax in every configured language
+func SearchQueryExpr(languages []string, arg string) string {
+	parts := make([]string, 0, len(languages))
+	for _, lang := range languages {
+		parts = append(parts, fmt.Sprintf(`websearch_to_tsquery('%s'::regconfig, %s)`, lang, arg))
+	}
+	return strings.Join(parts, " || ")
+}
+
+// SyncSearchColumn (re)creates the generated search_vector column and its GIN
+// index whenever the configured languages differ from the ones the column was
+// built with. The languages are kept in the column comment, so an unchanged
+// config costs one lookup. It runs after the migrations because the column
+// definition depends on the config, and holds the migration lock so replicas
+// starting together don't rebuild it at the same time.
+func SyncSearchColumn() error {
+	return withMigrationLock(syncSearchColumn)
+}
+
+func syncSearchColumn(conn *sqlx.Conn) error {
+	ctx := context.Background()
+	languages := SearchLanguages()
+	for _, lang := range languages {
+		if !searchLanguageRe.MatchString(lang) {
+			return fmt.Errorf("invalid search language %q", lang)
+		}
+	}
+	want := strings.Join(languages, ",")
+
+	var have sql.NullString
+	err := conn.GetContext(ctx, &have, `
+		SELECT col_description(a.attrelid, a.attnum)
+		FROM pg_attribute a
+		WHERE a.attrelid = 'confessions'::regclass
+		  AND a.attname = 'search_vector'
+		  AND NOT a.attisdropped`)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to inspect search column: %w", err)
+	}
+	if err == nil && have.String == want {
+		return nil
+	}
+
+	log.Printf("Building confessions search column for languages: %s...", want)
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	statements := []string{
+		`DROP INDEX IF EXISTS idx_confessions_search`,
+		`ALTER TABLE confessions DROP COLUMN IF EXISTS search_vector`,
+		`ALTER TABLE confessions ADD COLUMN search_vector tsvector
+			GENERATED ALWAYS AS (` + SearchVectorExpr(languages) + `) STORED`,
+		`CREATE INDEX idx_confessions_search ON confessions USING GIN (search_vector)`,
+		`COMMENT ON COLUMN confessions.search_vector IS '` + want + `'`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to build search column: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -1,6 +1,9 @@
 package errs
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	ErrConfessionNotFound             = errors.New("confession not found")
@@ -18,6 +21,79 @@ var (
 	ErrUserAlreadyBanned        = errors.New("user is already banned")
 	ErrUserNotBanned            = errors.New("user is not banned")
 	ErrYouCannotBanYourself     = errors.New("you cannot ban yourself")
-	ErrYouCannotBanOtherAdmin   = errors.New("you cannot ban other administrators")
+	ErrOutranked                = errors.New("you can only act on users with a lower role")
 	ErrInternalServer           = errors.New("internal server error")
-)
\ No newline at end of file
+	ErrInvalidCursor            = errors.New("invalid cursor")
+	ErrInvalidLimit             = errors.New("limit must be a positive integer")
+	ErrInvalidSort              = errors.New("invalid sort order")
+	ErrInvalidRefreshToken      = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused       = errors.New("refresh token has already been used, please log in again")
+	ErrTokenRevoked             = errors.New("token has been revoked")
+	ErrInvalidReactionKind      = errors.New("invalid reaction kind")
+	ErrCommentNotFound          = errors.New("comment not found")
+	ErrCommentTextEmpty         = errors.New("comment text is empty")
+	ErrCommentTooLong           = errors.New("comment is too long")
+	ErrCommentTooDeep           = errors.New("maximum reply depth reached")
+	ErrCommentParentMismatch    = errors.New("parent comment belongs to another confession")
+	ErrForbiddenComment         = errors.New("you don't have permission to modify this comment")
+	ErrInvalidReportStatus      = errors.New("invalid report status")
+	ErrInvalidReportTransition  = errors.New("report can't move to this status from its current one")
+	ErrInvalidResolutionAction  = errors.New("invalid resolution action")
+	ErrActionRequiresResolve    = errors.New("an action can only be given when resolving a report")
+	ErrActionTargetMismatch     = errors.New("resolution action doesn't fit the author of the reported content")
+	ErrReportedContentGone      = errors.New("reported content no longer exists")
+	ErrInvalidAuditFilter       = errors.New("invalid audit log filter")
+	ErrInvalidBanScope          = errors.New("invalid ban scope")
+	ErrBanExpiryInPast          = errors.New("ban expiry must be in the future")
+	ErrNoGuestToClaim           = errors.New("no guest identity to claim")
+	ErrGuestAlreadyClaimed      = errors.New("guest identity has already been claimed")
+	ErrGuestEditWindowClosed    = errors.New("guests can only change a confession shortly after posting it")
+	ErrChallengeRequired        = errors.New("a solved challenge is required, get one from /public/challenge")
+	ErrChallengeInvalid         = errors.New("invalid challenge")
+	ErrChallengeExpired         = errors.New("challenge has expired")
+	ErrChallengeUnsolved        = errors.New("challenge solution is wrong")
+	ErrChallengeUsed            = errors.New("challenge has already been used")
+	ErrInvalidConfessionStatus  = errors.New("invalid confession status")
+	ErrInvalidModerationTransition = errors.New("confession can't be moderated this way in its current status")
+	ErrContentRejected          = errors.New("text contains blocked words")
+	ErrDuplicateConfession      = errors.New("a very similar confession was posted recently")
+	ErrInvalidBulkAction        = errors.New("invalid bulk action")
+	ErrInvalidBulkOperation     = errors.New("invalid bulk operation")
+	ErrBulkTooLarge             = errors.New("bulk request is too large")
+	ErrInvalidFilterPattern     = errors.New("invalid filter pattern")
+	ErrInvalidFilterKind        = errors.New("invalid filter kind")
+	ErrInvalidFilterAction      = errors.New("invalid filter action")
+	ErrInvalidRole              = errors.New("invalid role")
+	ErrLastOwner                = errors.New("the last owner can't be demoted")
+	ErrInvalidEmailToken        = errors.New("invalid or expired token")
+	ErrEmailAlreadyVerified     = errors.New("email is already verified")
+	ErrTwoFactorAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorSetupMissing    = errors.New("start two-factor setup first")
+	ErrInvalidTwoFactorCode     = errors.New("invalid two-factor code")
+	ErrInvalidLoginChallenge    = errors.New("invalid or expired login challenge, please log in again")
+	ErrTwoFactorRequired        = errors.New("two-factor authentication is required for this account, set it up at /auth/2fa/setup")
+	ErrWeakPassword             = errors.New("password is too weak")
+	ErrEmptyUsernameOrEmail     = errors.New("username and email must not be empty")
+	ErrSamePassword             = errors.New("new password must differ from the current one")
+	ErrPasswordChangeRequired   = errors.New("password change required, set a new one at /auth/password/change")
+	ErrInvalidBootstrapToken    = errors.New("invalid or expired bootstrap token")
+	ErrInvalidLockoutScope      = errors.New("lockout scope must be account or ip")
+)
+
+// BanError tells a banned user or guest why and for how long they are banned.
+// It matches ErrUserBanned with errors.Is.
+type BanError struct {
+	Message   string
+	Scope     string
+	Reason    string
+	ExpiresAt *time.Time // nil for a permanent ban
+}
+
+func (e *BanError) Error() string {
+	return e.Message
+}
+
+func (e *BanError) Unwrap() error {
+	return ErrUserBanned
+}
@@ -1,13 +1,20 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
+	"github.com/hadisjane/confessly/internal/configs"
 	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
 	"github.com/hadisjane/confessly/internal/models"
 	"github.com/hadisjane/confessly/internal/service"
 	"github.com/hadisjane/confessly/logger"
 	"github.com/hadisjane/confessly/utils"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -19,6 +26,8 @@ const (
 	UsernameCtx         = "username"
 	RoleCtx             = "role"
 	GuestUUIDCtx        = "guestUUID"
+	ClaimsCtx           = "claims"
+	guestCookie         = "guest_uuid"
 )
 
 func CheckUserAuthentication(c *gin.Context) {
@@ -61,40 +70,67 @@ func CheckUserAuthentication(c *gin.Context) {
 		return
 	}
 
-	// Check if user is banned
-	isBanned, err := service.IsUserBanned(claims.UserID)
+	// Check if the token was revoked by logout
+	isRevoked, err := service.IsAccessTokenRevoked(claims)
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check user status"})
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check token status"})
 		return
 	}
 
-	if isBanned {
-		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-			"error": "your account has been banned",
+	if isRevoked {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "token has been revoked",
 		})
 		return
 	}
 
+	// Check if user is banned
+	if err := service.CheckUserBan(claims.UserID, models.BanScopeLogin); err != nil {
+		abortWithBanCheck(c, err, "failed to check user status")
+		return
+	}
+
 	c.Set(UserIDCtx, claims.UserID)
 	c.Set(UsernameCtx, claims.Username)
 	c.Set(RoleCtx, claims.Role)
+	c.Set(ClaimsCtx, claims)
 	c.Next()
 }
 
-func CheckAdminAuthentication(c *gin.Context) {
-	role, exists := c.Get(RoleCtx)
-	logger.Info.Printf("Role from context: %v, exists: %v", role, exists)
-	
-	roleStr, ok := role.(string)
-	if !ok || roleStr != "admin" {
-		 c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-		  	  "error": "forbidden: admin role required",
-		 })
-		 return
+// RequirePermission lets a request through only if the role of the user
+// grants the permission
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "forbidden: " + permission + " permission required",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireTwoFactor keeps staff accounts without 2FA out when
+// auth_params.require_two_factor is on
+func RequireTwoFactor(c *gin.Context) {
+	err := service.CheckTwoFactorRequirement(c.GetInt(UserIDCtx), c.GetString(RoleCtx))
+	if errors.Is(err, errs.ErrTwoFactorRequired) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check two-factor authentication"})
+		return
 	}
 	c.Next()
 }
 
+// HasPermission reports whether the user making the request has a permission
+func HasPermission(c *gin.Context, permission string) bool {
+	return models.HasPermission(c.GetString(RoleCtx), permission)
+}
+
 func TryParseUserContext(c *gin.Context) {
 	header := c.GetHeader(authorizationHeader)
 	if header == "" {
@@ -116,23 +152,28 @@ func TryParseUserContext(c *gin.Context) {
 		return
 	}
 
-	// Check if user is banned
-	isBanned, err := service.IsUserBanned(claims.UserID)
+	// A revoked token is treated like no token at all
+	isRevoked, err := service.IsAccessTokenRevoked(claims)
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check user status"})
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check token status"})
 		return
 	}
 
-	if isBanned {
-		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-			"error": "your account has been banned",
-		})
+	if isRevoked {
+		c.Next()
+		return
+	}
+
+	// Check if user is banned
+	if err := service.CheckUserBan(claims.UserID, models.BanScopeLogin); err != nil {
+		abortWithBanCheck(c, err, "failed to check user status")
 		return
 	}
 
 	c.Set(UserIDCtx, claims.UserID)
 	c.Set(UsernameCtx, claims.Username)
 	c.Set(RoleCtx, claims.Role)
+	c.Set(ClaimsCtx, claims)
 
 	c.Next()
 }
@@ -146,15 +187,28 @@ func GuestUUIDMiddleware() gin.HandlerFunc {
 		}
 
 		// Check for guest UUID in cookie
-		guestUUID, err := c.Cookie("guest_uuid")
-		if err != nil || guestUUID == "" {
+		cookie, err := c.Cookie(guestCookie)
+		if err != nil || cookie == "" {
 			// If no cookie, generate a new guest UUID and create user
 			createNewGuestUser(c)
 			return
 		}
 
+		guestUUID, reissue, err := readGuestCookie(cookie)
+		if errors.Is(err, utils.ErrInvalidGuestToken) {
+			// A forged, expired or reused cookie gets a fresh guest
+			logger.Warn.Printf("Rejected invalid guest cookie from %s", c.ClientIP())
+			createNewGuestUser(c)
+			return
+		}
+		if err != nil {
+			logger.Error.Printf("Failed to read guest cookie: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check guest status"})
+			return
+		}
+
 		// Check if guest exists in database
-		_, err = service.GetGuestUser(guestUUID)
+		guest, err := service.GetGuestUser(guestUUID)
 		if err != nil {
 			// If guest doesn't exist, create a new one
 			logger.Info.Printf("Guest user %s not found, creating new one", guestUUID)
@@ -162,18 +216,20 @@ func GuestUUIDMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Check if guest is banned
-		isBanned, err := service.IsGuestBanned(guestUUID)
-		if err != nil {
-			logger.Error.Printf("Failed to check guest ban status: %v", err)
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check guest status"})
+		// A claimed guest belongs to a user now, so the browser starts over
+		if guest.MergedAt != nil {
+			createNewGuestUser(c)
 			return
 		}
 
-		if isBanned {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"error": "your guest account has been banned",
-			})
+		// Issued before the ban check so a banned guest keeps its identity
+		if reissue {
+			setGuestCookie(c, utils.SignGuestToken(guestUUID, time.Now()))
+		}
+
+		// Check if guest is banned
+		if err := service.CheckGuestBan(guestUUID, models.BanScopeLogin); err != nil {
+			abortWithBanCheck(c, err, "failed to check guest status")
 			return
 		}
 
@@ -182,12 +238,162 @@ func GuestUUIDMiddleware() gin.HandlerFunc {
 	}
 }
 
+// CheckPostingBan stops users and guests that are banned from posting.
+// It must run after the identity has been put in the context.
+func CheckPostingBan(c *gin.Context) {
+	var err error
+	if userID, exists := c.Get(UserIDCtx); exists {
+		err = service.CheckUserBan(userID.(int), models.BanScopePosting)
+	} else if guestUUID, exists := c.Get(GuestUUIDCtx); exists {
+		err = service.CheckGuestBan(guestUUID.(string), models.BanScopePosting)
+	}
+
+	if err != nil {
+		abortWithBanCheck(c, err, "failed to check ban status")
+		return
+	}
+	c.Next()
+}
+
+// RateLimit limits requests by the named policy from rate_limit_params. On
+// routes that count by identity it must run after the identity is known.
+func RateLimit(policy string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !takeRateLimit(c, policy) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// takeRateLimit sets the RateLimit-* headers and answers 429 when the caller
+// is out of requests. It reports whether the request may go on.
+func takeRateLimit(c *gin.Context, policy string) bool {
+	var userID *int
+	var guestUUID *string
+	if id := c.GetInt(UserIDCtx); id != 0 {
+		userID = &id
+	} else if guest := c.GetString(GuestUUIDCtx); guest != "" {
+		guestUUID = &guest
+	}
+
+	result, err := service.TakeRateLimit(policy, userID, guestUUID, c.ClientIP())
+	if err != nil {
+		logger.Error.Printf("Failed to check rate limit %s: %v", policy, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check rate limit"})
+		return false
+	}
+	if result.Limit == 0 {
+		return true
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.Limit, result.Window))
+
+	if !result.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error": "too many requests, try again later",
+		})
+		return false
+	}
+	return true
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// AbortWithBan answers 403 with the details of the ban in force
+func AbortWithBan(c *gin.Context, banErr *errs.BanError) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error":      banErr.Message,
+		"scope":      banErr.Scope,
+		"reason":     banErr.Reason,
+		"expires_at": banErr.ExpiresAt,
+	})
+}
+
+func abortWithBanCheck(c *gin.Context, err error, message string) {
+	var banErr *errs.BanError
+	if errors.As(err, &banErr) {
+		AbortWithBan(c, banErr)
+		return
+	}
+	logger.Error.Printf("Failed to check ban status: %v", err)
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
+// readGuestCookie returns the guest UUID of a cookie and whether the cookie
+// has to be signed again: with the current key, later in its lifetime or for
+// the first time.
+func readGuestCookie(cookie string) (string, bool, error) {
+	token, err := utils.ParseGuestToken(cookie)
+	if err == nil {
+		reissue := !token.SignedWithCurrentKey() || time.Since(token.IssuedAt) > utils.GuestCookieTTL()/2
+		return token.UUID, reissue, nil
+	}
+
+	// Cookies from before signing hold the bare UUID. Each guest may trade
+	// one in for a signed cookie once.
+	if !utils.IsLegacyGuestCookie(cookie) {
+		return "", false, utils.ErrInvalidGuestToken
+	}
+	upgraded, err := service.UpgradeLegacyGuestCookie(cookie)
+	if err != nil {
+		return "", false, err
+	}
+	if !upgraded {
+		return "", false, utils.ErrInvalidGuestToken
+	}
+	return cookie, true, nil
+}
+
+// GuestCookie returns the guest UUID from a validly signed cookie, or "" without one
+func GuestCookie(c *gin.Context) string {
+	cookie, _ := c.Cookie(guestCookie)
+	token, err := utils.ParseGuestToken(cookie)
+	if err != nil {
+		return ""
+	}
+	return token.UUID
+}
+
+// ClearGuestCookie drops the guest cookie once its guest has been claimed
+func ClearGuestCookie(c *gin.Context) {
+	writeGuestCookie(c, "", -1)
+}
+
+func setGuestCookie(c *gin.Context, value string) {
+	writeGuestCookie(c, value, int(utils.GuestCookieTTL().Seconds()))
+}
+
+func writeGuestCookie(c *gin.Context, value string, maxAge int) {
+	params := configs.AppSettings.GuestParams
+	switch params.CookieSameSite {
+	case "strict":
+		c.SetSameSite(http.SameSiteStrictMode)
+	case "none":
+		c.SetSameSite(http.SameSiteNoneMode)
+	default:
+		c.SetSameSite(http.SameSiteLaxMode)
+	}
+	c.SetCookie(guestCookie, value, maxAge, "/", params.CookieDomain, params.CookieSecure, true)
+}
+
 func createNewGuestUser(c *gin.Context) {
+	// Every guest is a new row, so clients that drop the cookie are limited by IP
+	if !takeRateLimit(c, models.RateLimitNewGuest) {
+		return
+	}
+
 	// Generate a new guest UUID
 	guestUUID := uuid.New().String()
 	
-	// Set cookie with new UUID
-	c.SetCookie("guest_uuid", guestUUID, 30*24*60*60, "/", "", false, true)
+	// Set a signed cookie with the new UUID
+	setGuestCookie(c, utils.SignGuestToken(guestUUID, time.Now()))
 
 	// Create and save new guest user
 	guest := models.GuestUser{
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Target types of moderation actions
+const (
+	TargetUser       = "user"
+	TargetGuest      = "guest"
+	TargetConfession = "confession"
+	TargetComment    = "comment"
+	TargetReport     = "report"
+	TargetFilter     = "content_filter"
+	TargetLockout    = "login_lockout"
+)
+
+// Actions recorded in the audit log
+const (
+	AuditBanUser           = "ban_user"
+	AuditUnbanUser         = "unban_user"
+	AuditBanGuest          = "ban_guest"
+	AuditUnbanGuest        = "unban_guest"
+	AuditDeleteConfession  = "delete_confession"
+	AuditHideConfession    = "hide_confession"
+	AuditApproveConfession = "approve_confession"
+	AuditRejectConfession  = "reject_confession"
+	AuditAutoHide          = "auto_hide_confession"
+	AuditAutoRestore       = "auto_restore_confession"
+	AuditRemoveComment     = "remove_comment"
+	AuditUpdateReport      = "update_report"
+	AuditCreateFilter      = "create_filter"
+	AuditUpdateFilter      = "update_filter"
+	AuditDeleteFilter      = "delete_filter"
+	AuditSetRole           = "set_role"
+	AuditClearLockout      = "clear_lockout"
+)
+
+// Actor is the staff member performing a moderation action
+type Actor struct {
+	ID   int
+	IP   string
+	Role string
+}
+
+// SystemActor performs the actions the server takes by itself. It shows up
+// in the audit log as actor 0.
+var SystemActor = Actor{}
+
+// ModerationAction is an entry of the moderation audit log. Before and After
+// are JSON snapshots of the target, null when it didn't exist.
+type ModerationAction struct {
+	ID            int             `json:"id" db:"id"`
+	ActorID       int             `json:"actor_id" db:"actor_id"`
+	ActorUsername *string         `json:"actor_username,omitempty" db:"actor_username"`
+	Action        string          `json:"action" db:"action"`
+	TargetType    string          `json:"target_type" db:"target_type"`
+	TargetID      string          `json:"target_id" db:"target_id"`
+	Reason        string          `json:"reason" db:"reason"`
+	Before        json.RawMessage `json:"before" db:"before" swaggertype:"object"`
+	After         json.RawMessage `json:"after" db:"after" swaggertype:"object"`
+	IP            string          `json:"ip" db:"ip"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+}
+
+// AuditFilter narrows down the audit log, zero values match everything
+type AuditFilter struct {
+	ActorID    int
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+}
+
+// ModerationReason is the optional body of admin actions
+type ModerationReason struct {
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Ban scopes. A login ban locks the user or guest out completely,
+// a posting ban only stops them from creating content.
+const (
+	BanScopeLogin   = "login"
+	BanScopePosting = "posting"
+)
+
+type Ban struct {
+	ID        int        `json:"id" db:"id"`
+	UserID    *int       `json:"user_id,omitempty" db:"user_id"`
+	GuestUUID *string    `json:"guest_uuid,omitempty" db:"guest_uuid"`
+	Scope     string     `json:"scope" db:"scope"`
+	Reason    string     `json:"reason" db:"reason"`
+	IssuedBy  *int       `json:"issued_by,omitempty" db:"issued_by"`
+	ExpiresAt *time.Time `json:"expires_at" db:"expires_at"` // nil for a permanent ban
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	LiftedAt  *time.Time `json:"lifted_at,omitempty" db:"lifted_at"`
+	LiftedBy  *int       `json:"lifted_by,omitempty" db:"lifted_by"`
+	Active    bool       `json:"active" db:"active"`
+}
+
+type BanRequest struct {
+	Reason    string     `json:"reason"`
+	Scope     string     `json:"scope"`      // login by default
+	ExpiresAt *time.Time `json:"expires_at"` // permanent when omitted
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// Bulk moderation actions
+const (
+	BulkDeleteConfession = "delete_confession"
+	BulkHideConfession   = "hide_confession"
+	BulkBanUser          = "ban_user"
+	BulkBanGuest         = "ban_guest"
+	BulkResolveReport    = "resolve_report"
+)
+
+// Outcomes of a bulk operation
+const (
+	BulkOK         = "ok"
+	BulkFailed     = "failed"
+	BulkRolledBack = "rolled_back" // succeeded, but an atomic request failed later
+	BulkSkipped    = "skipped"     // not run because an atomic request had failed
+)
+
+// BulkRequest is a list of moderation operations. Each operation runs in its
+// own transaction unless Atomic asks for all or nothing.
+type BulkRequest struct {
+	Operations []BulkOperation `json:"operations"`
+	Atomic     bool            `json:"atomic"`
+	Reason     string          `json:"reason"` // for every operation that doesn't give its own
+}
+
+// BulkOperation is one moderation action. Confession actions take either a
+// confession_id or a filter selecting confessions.
+type BulkOperation struct {
+	Action       string            `json:"action"`
+	ConfessionID *int              `json:"confession_id,omitempty"`
+	Filter       *ConfessionFilter `json:"filter,omitempty"`
+	UserID       *int              `json:"user_id,omitempty"`
+	GuestUUID    *string           `json:"guest_uuid,omitempty"`
+	Scope        string            `json:"scope,omitempty"`      // of a ban, login by default
+	ExpiresAt    *time.Time        `json:"expires_at,omitempty"` // of a ban, permanent when omitted
+	ReportID     *int              `json:"report_id,omitempty"`
+	Status       string            `json:"status,omitempty"`        // of a report, resolved by default or dismissed
+	ReportAction *string           `json:"report_action,omitempty"` // a report is resolved with, none by default
+	Reason       string            `json:"reason,omitempty"`
+
+	// Filled in from the fields above before the operation runs
+	ConfessionIDs []int        `json:"-"`
+	Ban           Ban          `json:"-"`
+	ReportUpdate  UpdateReport `json:"-"`
+}
+
+// ConfessionFilter selects the confessions of a user or guest, optionally
+// within a time range
+type ConfessionFilter struct {
+	UserID    *int       `json:"user_id,omitempty"`
+	GuestUUID *string    `json:"guest_uuid,omitempty"`
+	Since     *time.Time `json:"since,omitempty"`
+	Until     *time.Time `json:"until,omitempty"`
+}
+
+// BulkResult is the outcome of the operation at Index in the request
+type BulkResult struct {
+	Index         int    `json:"index"`
+	Action        string `json:"action"`
+	Status        string `json:"status"`
+	ConfessionIDs []int  `json:"confession_ids,omitempty"` // the confessions a confession action covered
+	Error         string `json:"error,omitempty"`
+}
+
+type BulkResponse struct {
+	Atomic    bool         `json:"atomic"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// ChallengeAlgorithm is how a challenge is solved: find a solution such that
+// SHA-256 of "<challenge>:<solution>" starts with Difficulty zero bits
+const ChallengeAlgorithm = "sha256"
+
+type Challenge struct {
+	Required   bool       `json:"required"` // false for registered users
+	Challenge  string     `json:"challenge,omitempty"`
+	Algorithm  string     `json:"algorithm,omitempty"`
+	Difficulty int        `json:"difficulty,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// SolvedChallenge is a checked challenge. Its nonce is spent together with
+// the confession it was solved for.
+type SolvedChallenge struct {
+	Nonce     string
+	ExpiresAt time.Time
+}
+
+// GuestPostingStats is what the difficulty of a guest's challenge depends on
+type GuestPostingStats struct {
+	GuestVolume int `db:"guest_volume"` // confessions by all guests in the last hour
+	Recent      int `db:"recent"`       // confessions by this guest in the last hour
+	Hidden      int `db:"hidden"`       // confessions by this guest hidden or rejected by moderators
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Comment statuses
+const (
+	CommentVisible = "visible"
+	CommentDeleted = "deleted" // deleted by its author
+	CommentRemoved = "removed" // removed by an administrator
+)
+
+// CommentAliasFormat builds the pseudonym of an anonymous commenter
+// from an animal name and the commenter's number in the thread
+const CommentAliasFormat = "Anonymous %s #%d"
+
+// CommentAliasAnimals are the animal names used in anonymous pseudonyms
+var CommentAliasAnimals = []string{
+	"Badger", "Bear", "Beaver", "Bison", "Cat", "Crow", "Deer", "Dolphin",
+	"Eagle", "Falcon", "Fox", "Frog", "Hare", "Hedgehog", "Heron", "Lynx",
+	"Moose", "Otter", "Owl", "Panda", "Raccoon", "Raven", "Seal", "Sparrow",
+	"Squirrel", "Swan", "Tiger", "Turtle", "Walrus", "Wolf",
+}
+
+type Comment struct {
+	ID           int        `json:"id" db:"id"`
+	ConfessionID int        `json:"confession_id" db:"confession_id"`
+	ParentID     *int       `json:"parent_id,omitempty" db:"parent_id"`
+	Depth        int        `json:"depth" db:"depth"`
+	UserID       *int       `json:"user_id,omitempty" db:"user_id"`
+	GuestUUID    *string    `json:"guest_uuid,omitempty" db:"guest_uuid"`
+	Username     string     `json:"username,omitempty" db:"username"`
+	Anon         bool       `json:"anon" db:"anon"`
+	Alias        *string    `json:"alias,omitempty" db:"alias"`
+	IsOP         bool       `json:"is_op" db:"-"`
+	Text         string     `json:"text" db:"text"`
+	Status       string     `json:"status" db:"status"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	Replies      []*Comment `json:"replies,omitempty" db:"-"`
+}
+
+type CreateCommentRequest struct {
+	Text     string `json:"text" binding:"required"`
+	Anon     bool   `json:"anon"`
+	ParentID *int   `json:"parent_id"`
+}
+
+type UpdateCommentRequest struct {
+	Text string `json:"text" binding:"required"`
+}
@@ -2,14 +2,67 @@ package models
 
 import "time"
 
+// Confession statuses. Only published confessions are public; pending ones
+// wait in the pre-moderation queue.
+const (
+	ConfessionPending   = "pending"
+	ConfessionPublished = "published"
+	ConfessionRejected  = "rejected"
+	ConfessionHidden    = "hidden"
+)
+
+var ConfessionStatuses = []string{ConfessionPending, ConfessionPublished, ConfessionRejected, ConfessionHidden}
+
+// Moderation modes
+const (
+	ModerationPost      = "post"       // everything is published right away
+	ModerationPreGuests = "pre_guests" // guest confessions wait for approval
+	ModerationPreAll    = "pre_all"    // every confession waits for approval
+)
+
 type Confession struct {
-	ID        int        `json:"id" db:"id"`
-	UserID    *int       `json:"user_id,omitempty" db:"user_id"`
-	GuestUUID *string    `json:"guest_uuid,omitempty" db:"guest_uuid"`
-	Username  string     `json:"username,omitempty" db:"username"`
-	Title     string     `json:"title" binding:"required,min=5,max=100" db:"title"`
-	Text      string     `json:"text" binding:"required" db:"text"`
-	Anon      bool       `json:"anon" db:"anon"`
-	CreatedAt time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
-}
\ No newline at end of file
+	ID               int            `json:"id" db:"id"`
+	UserID           *int           `json:"user_id,omitempty" db:"user_id"`
+	GuestUUID        *string        `json:"guest_uuid,omitempty" db:"guest_uuid"`
+	Username         string         `json:"username,omitempty" db:"username"`
+	Title            string         `json:"title" binding:"required,min=5,max=100" db:"title"`
+	Text             string         `json:"text" binding:"required" db:"text"`
+	Anon             bool           `json:"anon" db:"anon"`
+	Status           string         `json:"status" db:"status"`
+	ModerationReason *string        `json:"moderation_reason,omitempty" db:"moderation_reason"` // why it was rejected
+	AutoHiddenAt     *time.Time     `json:"auto_hidden_at,omitempty" db:"auto_hidden_at"`       // hidden by reports until review
+	Fingerprint      *int64         `json:"-" db:"fingerprint"`                                 // SimHash of the normalized title and text
+	CreatedAt        time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at" db:"updated_at"`
+	Reactions        map[string]int `json:"reactions" db:"-"`
+}
+
+// SimilarConfession is a near-duplicate of another confession. Distance is
+// the number of differing fingerprint bits, 0 for the same text.
+type SimilarConfession struct {
+	Confession
+	Distance int `json:"distance" db:"distance"`
+}
+
+// ConfessionAuthor is someone who posted near-duplicates, with how many
+type ConfessionAuthor struct {
+	UserID      *int    `json:"user_id,omitempty"`
+	GuestUUID   *string `json:"guest_uuid,omitempty"`
+	Username    string  `json:"username"`
+	Confessions int     `json:"confessions"`
+}
+
+// Actions for near-duplicates of recent confessions
+const (
+	DuplicateReject   = "reject"
+	DuplicateModerate = "moderate"
+)
+
+// ConfessionSearchResult is a confession matched by full-text search
+type ConfessionSearchResult struct {
+	Confession
+	Rank float64 `json:"rank" db:"rank"`
+	// HTML-escaped, with the matches wrapped in the highlight markers
+	TitleHighlight string `json:"title_highlight" db:"title_highlight"`
+	Snippet        string `json:"snippet" db:"snippet"`
+}
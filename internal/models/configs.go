@@ -1,40 +1,186 @@
 package models
 
+// Every setting can be overridden from the environment. The env tag lists the
+// variable names to look up, the first one that is set wins. Lists of strings
+// are comma-separated, structured settings are JSON.
+
 type Configs struct {
-	AuthParams     AuthParams     `json:"auth_params"`
-	LogParams      LogParams      `json:"log_params"`
-	AppParams      AppParams      `json:"app_params"`
-	PostgresParams PostgresParams `json:"postgres_params"`
+	AuthParams       AuthParams       `json:"auth_params"`
+	LogParams        LogParams        `json:"log_params"`
+	AppParams        AppParams        `json:"app_params"`
+	PostgresParams   PostgresParams   `json:"postgres_params"`
+	PageParams       PageConfig       `json:"page_params"`
+	SearchParams     SearchParams     `json:"search_params"`
+	CommentParams    CommentParams    `json:"comment_params"`
+	GuestParams      GuestParams      `json:"guest_params"`
+	RateLimitParams  RateLimitParams  `json:"rate_limit_params"`
+	ChallengeParams  ChallengeParams  `json:"challenge_params"`
+	ModerationParams ModerationParams `json:"moderation_params"`
+	AutoHideParams   AutoHideParams   `json:"auto_hide_params"`
+	DuplicateParams  DuplicateParams  `json:"duplicate_params"`
+	MailParams       MailParams       `json:"mail_params"`
+	LockoutParams    LockoutParams    `json:"lockout_params"`
 }
 type AuthParams struct {
-	JwtSecretKey  string `json:"jwt_secret_key"`
-	JwtTtlMinutes int    `json:"jwt_ttl_minutes"`
+	JwtSecretKey    string `json:"jwt_secret_key" env:"CONFESSLY_JWT_SECRET_KEY,JWT_SECRET_KEY,JWT_SECRET"`
+	JwtTtlMinutes   int    `json:"jwt_ttl_minutes" env:"CONFESSLY_JWT_TTL_MINUTES"`
+	RefreshTtlHours int    `json:"refresh_ttl_hours" env:"CONFESSLY_REFRESH_TTL_HOURS"`
+	// Lifetime of the single-use tokens sent by email
+	EmailVerifyTtlHours     int `json:"email_verify_ttl_hours" env:"CONFESSLY_EMAIL_VERIFY_TTL_HOURS"`
+	PasswordResetTtlMinutes int `json:"password_reset_ttl_minutes" env:"CONFESSLY_PASSWORD_RESET_TTL_MINUTES"`
+	// With RequireTwoFactor, staff accounts can't use /api/admin until they enroll in 2FA
+	RequireTwoFactor             bool `json:"require_two_factor" env:"CONFESSLY_REQUIRE_TWO_FACTOR"`
+	TwoFactorChallengeTtlSeconds int  `json:"two_factor_challenge_ttl_seconds" env:"CONFESSLY_TWO_FACTOR_CHALLENGE_TTL_SECONDS"`
+	// Print a one-time token for creating the first owner while there is none
+	BootstrapToken bool `json:"bootstrap_token" env:"CONFESSLY_BOOTSTRAP_TOKEN"`
 }
 
 type LogParams struct {
-	LogDirectory     string `json:"log_directory"`
-	LogInfo          string `json:"log_info"`
-	LogError         string `json:"log_error"`
-	LogWarn          string `json:"log_warn"`
-	LogDebug         string `json:"log_debug"`
-	MaxSizeMegabytes int    `json:"max_size_megabytes"`
-	MaxBackups       int    `json:"max_backups"`
-	MaxAgeDays       int    `json:"max_age_days"`
-	Compress         bool   `json:"compress"`
-	LocalTime        bool   `json:"local_time"`
+	LogDirectory     string `json:"log_directory" env:"CONFESSLY_LOG_DIRECTORY"`
+	LogInfo          string `json:"log_info" env:"CONFESSLY_LOG_INFO"`
+	LogError         string `json:"log_error" env:"CONFESSLY_LOG_ERROR"`
+	LogWarn          string `json:"log_warn" env:"CONFESSLY_LOG_WARN"`
+	LogDebug         string `json:"log_debug" env:"CONFESSLY_LOG_DEBUG"`
+	MaxSizeMegabytes int    `json:"max_size_megabytes" env:"CONFESSLY_LOG_MAX_SIZE_MEGABYTES"`
+	MaxBackups       int    `json:"max_backups" env:"CONFESSLY_LOG_MAX_BACKUPS"`
+	MaxAgeDays       int    `json:"max_age_days" env:"CONFESSLY_LOG_MAX_AGE_DAYS"`
+	Compress         bool   `json:"compress" env:"CONFESSLY_LOG_COMPRESS"`
+	LocalTime        bool   `json:"local_time" env:"CONFESSLY_LOG_LOCAL_TIME"`
 }
 
 type AppParams struct {
-	ServerURL  string `json:"server_url"`
-	ServerName string `json:"server_name"`
-	AppVersion string `json:"app_version"`
-	PortRun    string `json:"port_run"`
-	GinMode    string `json:"gin_mode"`
+	ServerURL  string `json:"server_url" env:"CONFESSLY_SERVER_URL"`
+	ServerName string `json:"server_name" env:"CONFESSLY_SERVER_NAME"`
+	AppVersion string `json:"app_version" env:"CONFESSLY_APP_VERSION"`
+	PortRun    string `json:"port_run" env:"CONFESSLY_PORT_RUN"`
+	GinMode    string `json:"gin_mode" env:"CONFESSLY_GIN_MODE,GIN_MODE"`
+	// Addresses or CIDR ranges of reverse proxies whose X-Forwarded-For and
+	// X-Real-IP are believed. Empty means the client IP is the peer address.
+	TrustedProxies []string `json:"trusted_proxies" env:"CONFESSLY_TRUSTED_PROXIES"`
 }
 
 type PostgresParams struct {
-	User     string `json:"user"`
-	Host     string `json:"host"`
-	Port     string `json:"port"`
-	Database string `json:"database"`
+	User         string `json:"user" env:"CONFESSLY_DB_USER"`
+	Password     string `json:"password" env:"CONFESSLY_DB_PASSWORD,DB_PASSWORD"`
+	Host         string `json:"host" env:"CONFESSLY_DB_HOST"`
+	Port         string `json:"port" env:"CONFESSLY_DB_PORT"`
+	Database     string `json:"database" env:"CONFESSLY_DB_NAME"`
+	SSLMode      string `json:"ssl_mode" env:"CONFESSLY_DB_SSL_MODE"`
+	MaxOpenConns int    `json:"max_open_conns" env:"CONFESSLY_DB_MAX_OPEN_CONNS"`
+	MaxIdleConns int    `json:"max_idle_conns" env:"CONFESSLY_DB_MAX_IDLE_CONNS"`
+}
+
+type PageConfig struct {
+	DefaultLimit int `json:"default_limit" env:"CONFESSLY_PAGE_DEFAULT_LIMIT"`
+	MaxLimit     int `json:"max_limit" env:"CONFESSLY_PAGE_MAX_LIMIT"`
+}
+
+type SearchParams struct {
+	Languages      []string `json:"languages" env:"CONFESSLY_SEARCH_LANGUAGES"`
+	HighlightStart string   `json:"highlight_start" env:"CONFESSLY_SEARCH_HIGHLIGHT_START"`
+	HighlightStop  string   `json:"highlight_stop" env:"CONFESSLY_SEARCH_HIGHLIGHT_STOP"`
+}
+
+type CommentParams struct {
+	MaxDepth  int `json:"max_depth" env:"CONFESSLY_COMMENT_MAX_DEPTH"`
+	MaxLength int `json:"max_length" env:"CONFESSLY_COMMENT_MAX_LENGTH"`
+}
+
+type GuestParams struct {
+	// How long after posting a guest may still edit or delete a confession
+	EditWindowMinutes int `json:"edit_window_minutes" env:"CONFESSLY_GUEST_EDIT_WINDOW_MINUTES"`
+
+	// Keys that sign guest cookies. The first one signs new cookies, the
+	// others are still accepted so a key can be rotated without losing guests.
+	CookieKeys       []string `json:"cookie_keys" env:"CONFESSLY_GUEST_COOKIE_KEYS"`
+	CookieMaxAgeDays int      `json:"cookie_max_age_days" env:"CONFESSLY_GUEST_COOKIE_MAX_AGE_DAYS"`
+	CookieSecure     bool     `json:"cookie_secure" env:"CONFESSLY_GUEST_COOKIE_SECURE"`
+	CookieSameSite   string   `json:"cookie_same_site" env:"CONFESSLY_GUEST_COOKIE_SAME_SITE"` // lax, strict or none
+	CookieDomain     string   `json:"cookie_domain" env:"CONFESSLY_GUEST_COOKIE_DOMAIN"`
+}
+
+type RateLimitParams struct {
+	Enabled  bool                       `json:"enabled" env:"CONFESSLY_RATE_LIMIT_ENABLED"`
+	Backend  string                     `json:"backend" env:"CONFESSLY_RATE_LIMIT_BACKEND"`   // memory or postgres for several replicas
+	Policies map[string]RateLimitPolicy `json:"policies" env:"CONFESSLY_RATE_LIMIT_POLICIES"` // JSON, merged by policy name
+}
+
+// RateLimitPolicy is a token bucket that holds Requests tokens and fills up
+// again within PerSeconds
+type RateLimitPolicy struct {
+	Requests   int    `json:"requests"`
+	PerSeconds int    `json:"per_seconds"`
+	Key        string `json:"key"` // identity or ip
+}
+
+// ChallengeParams configure the proof-of-work challenge guests solve before
+// posting. Difficulty is in leading zero bits, each bit doubles the work.
+type ChallengeParams struct {
+	Enabled        bool `json:"enabled" env:"CONFESSLY_CHALLENGE_ENABLED"`
+	BaseDifficulty int  `json:"base_difficulty" env:"CONFESSLY_CHALLENGE_BASE_DIFFICULTY"`
+	MaxDifficulty  int  `json:"max_difficulty" env:"CONFESSLY_CHALLENGE_MAX_DIFFICULTY"`
+	TTLSeconds     int  `json:"ttl_seconds" env:"CONFESSLY_CHALLENGE_TTL_SECONDS"`
+	// One more bit for every this many guest confessions in the last hour
+	VolumeStep int `json:"volume_step" env:"CONFESSLY_CHALLENGE_VOLUME_STEP"`
+}
+
+type ModerationParams struct {
+	Mode string `json:"mode" env:"CONFESSLY_MODERATION_MODE"` // post, pre_guests or pre_all
+}
+
+// AutoHideParams configure hiding a confession until review once enough
+// people report it. A confession is hidden as soon as one rule matches.
+type AutoHideParams struct {
+	Enabled bool           `json:"enabled" env:"CONFESSLY_AUTO_HIDE_ENABLED"`
+	Rules   []AutoHideRule `json:"rules" env:"CONFESSLY_AUTO_HIDE_RULES"` // JSON list
+	// Reporters whose accounts are younger than this count for NewAccountWeight
+	NewAccountHours  int     `json:"new_account_hours" env:"CONFESSLY_AUTO_HIDE_NEW_ACCOUNT_HOURS"`
+	NewAccountWeight float64 `json:"new_account_weight" env:"CONFESSLY_AUTO_HIDE_NEW_ACCOUNT_WEIGHT"`
+}
+
+// AutoHideRule matches when the open reports filed within WindowMinutes add
+// up to Reporters. A reporter weighs 1 at most, less for new accounts and for
+// reporters whose past reports were mostly dismissed.
+type AutoHideRule struct {
+	Reporters     float64 `json:"reporters"`
+	WindowMinutes int     `json:"window_minutes"`
+}
+
+// DuplicateParams configure catching near-duplicates of recent confessions.
+// Texts are compared by 64-bit SimHash fingerprints.
+type DuplicateParams struct {
+	Enabled     bool   `json:"enabled" env:"CONFESSLY_DUPLICATE_ENABLED"`
+	WindowHours int    `json:"window_hours" env:"CONFESSLY_DUPLICATE_WINDOW_HOURS"`
+	MaxDistance int    `json:"max_distance" env:"CONFESSLY_DUPLICATE_MAX_DISTANCE"` // differing bits, at most 64
+	Action      string `json:"action" env:"CONFESSLY_DUPLICATE_ACTION"`             // reject or moderate
+	// Shorter confessions aren't checked, their fingerprints collide too easily
+	MinLength int `json:"min_length" env:"CONFESSLY_DUPLICATE_MIN_LENGTH"`
+}
+
+// MailParams configure outgoing email. Links in emails point to LinkBaseURL,
+// the page there posts the token back to the API.
+type MailParams struct {
+	Backend      string `json:"backend" env:"CONFESSLY_MAIL_BACKEND"` // smtp, file or log
+	From         string `json:"from" env:"CONFESSLY_MAIL_FROM"`
+	LinkBaseURL  string `json:"link_base_url" env:"CONFESSLY_MAIL_LINK_BASE_URL"`
+	SMTPHost     string `json:"smtp_host" env:"CONFESSLY_SMTP_HOST"`
+	SMTPPort     int    `json:"smtp_port" env:"CONFESSLY_SMTP_PORT"`
+	SMTPUsername string `json:"smtp_username" env:"CONFESSLY_SMTP_USERNAME"`
+	SMTPPassword string `json:"smtp_password" env:"CONFESSLY_SMTP_PASSWORD"`
+	FilePath     string `json:"file_path" env:"CONFESSLY_MAIL_FILE_PATH"` // file backend only
+}
+
+// LockoutParams configure throttling of failed logins, counted per account and
+// per client IP. After each failure the next attempt has to wait
+// BaseDelaySeconds, doubling up to MaxDelaySeconds; once the failures reach
+// the limit of the scope, logins are locked for LockMinutes. Failures are
+// forgotten WindowMinutes after the last one.
+type LockoutParams struct {
+	Enabled            bool `json:"enabled" env:"CONFESSLY_LOCKOUT_ENABLED"`
+	AccountMaxFailures int  `json:"account_max_failures" env:"CONFESSLY_LOCKOUT_ACCOUNT_MAX_FAILURES"`
+	IPMaxFailures      int  `json:"ip_max_failures" env:"CONFESSLY_LOCKOUT_IP_MAX_FAILURES"`
+	BaseDelaySeconds   int  `json:"base_delay_seconds" env:"CONFESSLY_LOCKOUT_BASE_DELAY_SECONDS"`
+	MaxDelaySeconds    int  `json:"max_delay_seconds" env:"CONFESSLY_LOCKOUT_MAX_DELAY_SECONDS"`
+	LockMinutes        int  `json:"lock_minutes" env:"CONFESSLY_LOCKOUT_LOCK_MINUTES"`
+	WindowMinutes      int  `json:"window_minutes" env:"CONFESSLY_LOCKOUT_WINDOW_MINUTES"`
 }
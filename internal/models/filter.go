@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Kinds of content filter rules
+const (
+	FilterLiteral = "literal" // a whole word, compared after normalization
+	FilterRegex   = "regex"
+)
+
+// What happens to text a content filter rule matches
+const (
+	FilterReject   = "reject"   // the text is refused with an error
+	FilterMask     = "mask"     // the match is replaced with asterisks
+	FilterModerate = "moderate" // the confession waits for approval
+)
+
+var FilterKinds = []string{FilterLiteral, FilterRegex}
+
+var FilterActions = []string{FilterReject, FilterMask, FilterModerate}
+
+// ContentFilter is a blocklist rule checked against confession and report text
+type ContentFilter struct {
+	ID        int        `json:"id" db:"id"`
+	Pattern   string     `json:"pattern" db:"pattern"`
+	Kind      string     `json:"kind" db:"kind"`
+	Action    string     `json:"action" db:"action"`
+	Enabled   bool       `json:"enabled" db:"enabled"`
+	CreatedBy *int       `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// ContentFilterRequest creates a rule or changes the fields that are set.
+// A new rule is a literal, enabled and rejects text unless told otherwise.
+type ContentFilterRequest struct {
+	Pattern *string `json:"pattern"`
+	Kind    *string `json:"kind"`   // literal or regex
+	Action  *string `json:"action"` // reject, mask or moderate
+	Enabled *bool   `json:"enabled"`
+}
@@ -6,4 +6,6 @@ type GuestUser struct {
 	UUID   		string 		`json:"uuid" db:"uuid"`
 	Banned 		bool 			`json:"banned" db:"banned"`
 	CreatedAt 	time.Time	`json:"created_at" db:"created_at"`
+	MergedInto	*int		`json:"merged_into,omitempty" db:"merged_into"` // user who claimed this guest
+	MergedAt	*time.Time	`json:"merged_at,omitempty" db:"merged_at"`
 }
\ No newline at end of file
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Scopes of failed login tracking
+const (
+	LockoutAccount = "account" // keyed by the username tried
+	LockoutIP      = "ip"
+)
+
+// LockoutUnknownUsername is the account key all usernames without an account
+// share, so spraying made-up names doesn't add a row for every one of them
+const LockoutUnknownUsername = ""
+
+// LoginFailure counts the recent failed logins of an account or an IP.
+// Logins are refused until BlockedUntil: a short backoff that doubles with
+// every failure, or a lockout once LockedAt is set.
+type LoginFailure struct {
+	ID            int        `json:"id" db:"id"`
+	Scope         string     `json:"scope" db:"scope"`
+	Key           string     `json:"key" db:"key"`
+	UserID        *int       `json:"user_id" db:"user_id"`
+	Failures      int        `json:"failures" db:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at" db:"last_failure_at"`
+	BlockedUntil  time.Time  `json:"blocked_until" db:"blocked_until"`
+	LockedAt      *time.Time `json:"locked_at" db:"locked_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// LoginFailurePage is one page of the accounts and IPs that can't log in now
+type LoginFailurePage struct {
+	Lockouts   []LoginFailure `json:"lockouts"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Sort orders accepted by the list endpoints
+const (
+	SortNew       = "new"
+	SortOld       = "old"
+	SortRelevance = "relevance"
+)
+
+// PageParams holds the raw pagination parameters sent by the client
+type PageParams struct {
+	Limit  int
+	Cursor string
+	Sort   string
+}
+
+// Cursor is the decoded form of the opaque next_cursor token.
+// It points at the last row of the previous page.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+	Sort      string    `json:"s"`
+	Rank      float64   `json:"r,omitempty"`
+}
+
+// PageQuery is a validated page request passed down to the repository
+type PageQuery struct {
+	Limit int
+	Sort  string
+	After *Cursor
+}
+
+type ConfessionPage struct {
+	Confessions []Confession `json:"confessions"`
+	NextCursor  string       `json:"next_cursor,omitempty"`
+}
+
+type ConfessionSearchPage struct {
+	Confessions []ConfessionSearchResult `json:"confessions"`
+	NextCursor  string                   `json:"next_cursor,omitempty"`
+}
+
+type ReportPage struct {
+	Reports    []Report `json:"reports"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+type UserPage struct {
+	Users      []User `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type GuestUserPage struct {
+	GuestUsers []GuestUser `json:"guestUsers"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+type ModerationActionPage struct {
+	Actions    []ModerationAction `json:"actions"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
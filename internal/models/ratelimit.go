@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Rate limit policies, configured in rate_limit_params.policies
+const (
+	RateLimitCreateConfession = "create_confession"
+	RateLimitCreateComment    = "create_comment"
+	RateLimitCreateReport     = "create_report"
+	RateLimitLogin            = "login"
+	RateLimitRegister         = "register"
+	RateLimitNewGuest         = "new_guest"
+	RateLimitForgotPassword   = "forgot_password"
+	RateLimitResendVerify     = "resend_verification"
+)
+
+var RateLimitPolicyNames = []string{
+	RateLimitCreateConfession,
+	RateLimitCreateComment,
+	RateLimitCreateReport,
+	RateLimitLogin,
+	RateLimitRegister,
+	RateLimitNewGuest,
+	RateLimitForgotPassword,
+	RateLimitResendVerify,
+}
+
+// What a rate limit policy counts requests by
+const (
+	RateLimitByIdentity = "identity" // user ID, guest UUID or, without either, client IP
+	RateLimitByIP       = "ip"
+)
+
+// Rate limit backends
+const (
+	RateLimitMemory   = "memory"
+	RateLimitPostgres = "postgres"
+)
+
+// RateLimitBucket is a token bucket. Tokens are as of UpdatedAt.
+type RateLimitBucket struct {
+	Tokens    float64   `db:"tokens"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// RateLimitResult is the outcome of taking a token from a bucket
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int // 0 when the policy is not limited
+	Window     int // seconds the bucket takes to fill up
+	Remaining  int
+	RetryAfter time.Duration // until the next token when not allowed
+	Reset      time.Duration // until the bucket is full again
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Reaction kinds readers can leave on a confession
+const (
+	ReactionHug     = "hug"
+	ReactionSame    = "same"
+	ReactionSupport = "support"
+	ReactionWow     = "wow"
+)
+
+var ReactionKinds = []string{ReactionHug, ReactionSame, ReactionSupport, ReactionWow}
+
+type Reaction struct {
+	ID           int       `json:"id" db:"id"`
+	ConfessionID int       `json:"confession_id" db:"confession_id"`
+	Kind         string    `json:"kind" db:"kind"`
+	UserID       *int      `json:"user_id,omitempty" db:"user_id"`
+	GuestUUID    *string   `json:"guest_uuid,omitempty" db:"guest_uuid"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// Reactor is one entry of a confession's reactor list
+type Reactor struct {
+	Kind      string    `json:"kind" db:"kind"`
+	UserID    *int      `json:"user_id,omitempty" db:"user_id"`
+	GuestUUID *string   `json:"guest_uuid,omitempty" db:"guest_uuid"`
+	Username  string    `json:"username" db:"username"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ReactionSummary is returned by the reactions endpoint. Reactors is left
+// out for anonymous confessions.
+type ReactionSummary struct {
+	Counts      map[string]int `json:"counts"`
+	MyReactions []string       `json:"my_reactions"`
+	Reactors    []Reactor      `json:"reactors,omitempty"`
+}
@@ -1,17 +1,79 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"time"
+)
+
+// Report statuses
+const (
+	ReportPending   = "pending"
+	ReportInReview  = "in_review"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+// Actions a report can be resolved with. For a report on a comment the
+// hide and delete actions apply to the comment.
+const (
+	ActionNone             = "none"
+	ActionHideConfession   = "hide_confession"
+	ActionDeleteConfession = "delete_confession"
+	ActionBanAuthor        = "ban_author"
+	ActionBanGuest         = "ban_guest"
+)
+
+var ResolutionActions = []string{
+	ActionNone, ActionHideConfession, ActionDeleteConfession, ActionBanAuthor, ActionBanGuest,
+}
+
+// reportTransitions lists the statuses a report can move to from each status.
+// Closed reports can only be reopened.
+var reportTransitions = map[string][]string{
+	ReportPending:   {ReportInReview, ReportResolved, ReportDismissed},
+	ReportInReview:  {ReportPending, ReportResolved, ReportDismissed},
+	ReportResolved:  {ReportPending},
+	ReportDismissed: {ReportPending},
+}
+
+// IsReportStatus reports whether status is a known report status
+func IsReportStatus(status string) bool {
+	_, ok := reportTransitions[status]
+	return ok
+}
+
+// CanTransitionReport reports whether a report may move from one status to another
+func CanTransitionReport(from, to string) bool {
+	return slices.Contains(reportTransitions[from], to)
+}
 
 type Report struct {
 	ID        int       `json:"id" db:"id"`
 	UserID    int       `json:"user_id" db:"user_id"`
-	ConfessionID int     `json:"confession_id" db:"confession_id"`
+	ConfessionID *int    `json:"confession_id" db:"confession_id"` // nil once the confession is deleted
+	CommentID *int      `json:"comment_id,omitempty" db:"comment_id"`
 	Reason    string    `json:"reason" db:"reason"`
-	Status    string    `json:"status" db:"status"` // "pending", "approved", "rejected"
+	Status    string    `json:"status" db:"status"` // "pending", "in_review", "resolved", "dismissed"
+	Action     *string    `json:"action,omitempty" db:"action"`
+	ResolvedBy *int       `json:"resolved_by,omitempty" db:"resolved_by"`
+	Note       *string    `json:"note,omitempty" db:"note"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at" db:"updated_at,omitempty"`
 }
 
 type UpdateReport struct {
 	Status *string `json:"status" db:"status"`
-}
\ No newline at end of file
+	Action *string `json:"action,omitempty"` // only when resolving, "none" by default
+	Note   *string `json:"note,omitempty"`
+}
+
+// ReporterRecord is an open report on a confession together with what
+// auto-hiding weighs its reporter by
+type ReporterRecord struct {
+	UserID           int       `db:"user_id"`
+	ReportedAt       time.Time `db:"reported_at"`
+	AccountCreatedAt time.Time `db:"account_created_at"`
+	Resolved         int       `db:"resolved"`  // past reports of the reporter that were acted on
+	Dismissed        int       `db:"dismissed"` // past reports of the reporter that were dismissed
+}
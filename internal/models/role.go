@@ -0,0 +1,69 @@
+package models
+
+import "slices"
+
+// Roles from least to most privileged
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+	RoleOwner     = "owner"
+)
+
+var Roles = []string{RoleUser, RoleModerator, RoleAdmin, RoleOwner}
+
+// Permissions checked by the routes
+const (
+	PermReportsView         = "reports.view"
+	PermReportsResolve      = "reports.resolve"
+	PermConfessionsModerate = "confessions.moderate" // queues, approval, similar posts, unpublished confessions
+	PermConfessionsDelete   = "confessions.delete"
+	PermCommentsRemove      = "comments.remove"
+	PermUsersView           = "users.view" // users, guests and their bans
+	PermUsersBan            = "users.ban"  // users and guests
+	PermAuthorsReveal       = "authors.reveal"
+	PermAuditView           = "audit.view"
+	PermFiltersManage       = "filters.manage"
+	PermBulk                = "bulk.run" // each operation also needs its own permission
+	PermRolesAssign         = "roles.assign"
+	PermLockoutsManage      = "lockouts.manage" // failed login lockouts
+)
+
+var moderatorPermissions = []string{
+	PermReportsView, PermReportsResolve, PermConfessionsModerate, PermConfessionsDelete,
+	PermCommentsRemove, PermUsersView, PermUsersBan,
+}
+
+var adminPermissions = append(slices.Clone(moderatorPermissions),
+	PermAuthorsReveal, PermAuditView, PermFiltersManage, PermBulk, PermRolesAssign, PermLockoutsManage,
+)
+
+// RolePermissions lists what each role may do. Owners can do what admins can,
+// but their rank lets them manage admins.
+var RolePermissions = map[string][]string{
+	RoleUser:      {},
+	RoleModerator: moderatorPermissions,
+	RoleAdmin:     adminPermissions,
+	RoleOwner:     adminPermissions,
+}
+
+// IsRole reports whether role is a known role
+func IsRole(role string) bool {
+	return slices.Contains(Roles, role)
+}
+
+// HasPermission reports whether a role grants a permission
+func HasPermission(role, permission string) bool {
+	return slices.Contains(RolePermissions[role], permission)
+}
+
+// Outranks reports whether role a is more privileged than role b
+func Outranks(a, b string) bool {
+	return slices.Index(Roles, a) > slices.Index(Roles, b)
+}
+
+// RoleRequest changes the role of a user
+type RoleRequest struct {
+	Role   string `json:"role"`
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// TwoFactor is the TOTP state of a user. EnabledAt is nil until the secret
+// has been confirmed with a code.
+type TwoFactor struct {
+	UserID    int        `db:"id"`
+	Secret    *string    `db:"totp_secret"`
+	EnabledAt *time.Time `db:"totp_enabled_at"`
+	LastStep  int64      `db:"totp_last_step"`
+}
+
+// LoginChallenge is the pending second step of a login
+type LoginChallenge struct {
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	TokenHash string     `db:"token_hash"`
+	Attempts  int        `db:"attempts"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// TwoFactorStatus tells whether 2FA is on and how many recovery codes are left
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// TwoFactorSetup is shown once when enrolling. The secret goes into an
+// authenticator app, usually by scanning OTPAuthURL as a QR code.
+type TwoFactorSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// TwoFactorCodeRequest carries a code from the authenticator app or, where
+// accepted, a recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse lists new recovery codes. They are shown only once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorChallenge answers a correct password of a user with 2FA. The
+// challenge token and a code are exchanged at /auth/login/2fa.
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // TOTP or recovery code
+	ClaimGuest     bool   `json:"claim_guest"`             // take over the guest identity of the guest_uuid cookie
+}
@@ -10,15 +10,40 @@ type User struct {
 	Role      string    `json:"role" db:"role"`
 	Banned    bool      `json:"banned" db:"banned"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// nil until the user follows the link sent to their email
+	EmailVerifiedAt *time.Time `json:"email_verified_at" db:"email_verified_at"`
+	// Set while the account still has a known password, login is refused until it changes
+	PasswordChangeRequired bool `json:"-" db:"password_change_required"`
 }
 
 type UserRegister struct {
-	Username string `json:"username" binding:"required"`
-	Email    string `json:"email" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username   string `json:"username" binding:"required"`
+	Email      string `json:"email" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	ClaimGuest bool   `json:"claim_guest"` // take over the guest identity of the guest_uuid cookie
 }
 
 type UserLogin struct {
+	Username   string `json:"username" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	ClaimGuest bool   `json:"claim_guest"` // take over the guest identity of the guest_uuid cookie
+}
+
+// ChangePasswordRequest changes a password with the current one. It works
+// without a session, so accounts that must change their password can do it
+// before they are let in. With 2FA on it also takes a code from the
+// authenticator app or a recovery code.
+type ChangePasswordRequest struct {
+	Username        string `json:"username" binding:"required"`
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+	TwoFactorCode   string `json:"two_factor_code"`
+}
+
+// BootstrapRequest creates the first owner with the one-time bootstrap token
+type BootstrapRequest struct {
+	Token    string `json:"token" binding:"required"`
 	Username string `json:"username" binding:"required"`
+	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
-}
\ No newline at end of file
+}
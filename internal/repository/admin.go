@@ -5,32 +5,47 @@ import (
 	"github.com/hadisjane/confessly/internal/errs"
 	"github.com/hadisjane/confessly/internal/models"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
 )
 	
-func GetReports() []models.Report {
-	reports := make([]models.Report, 0) // Initialize empty slice
-	err := db.GetDB().Select(&reports, "SELECT * FROM reports")
+func GetReports(page models.PageQuery) ([]models.Report, error) {
+	reports := make([]models.Report, 0)
+
+	cond, order, args := keyset(page, "id", 1)
+	limit, limitArg := limitClause(page, len(args)+1)
+	args = append(args, limitArg)
+
+	err := db.GetDB().Select(&reports, "SELECT * FROM reports WHERE "+cond+" "+order+" "+limit, args...)
 	if err != nil {
-		return reports // Return empty slice instead of nil
-	}	
-	return reports
+		return nil, err
+	}
+	return reports, nil
 }
 
-func GetUsers() []models.User {
-	users := make([]models.User, 0) // Initialize empty slice
-	err := db.GetDB().Select(&users, "SELECT id, username, email, role, banned, created_at FROM users")
+func GetUsers(page models.PageQuery) ([]models.User, error) {
+	users := make([]models.User, 0)
+
+	cond, order, args := keyset(page, "id", 1)
+	limit, limitArg := limitClause(page, len(args)+1)
+	args = append(args, limitArg)
+
+	err := db.GetDB().Select(&users, "SELECT id, username, email, role, "+userBannedColumn+", created_at, email_verified_at FROM users WHERE "+cond+" "+order+" "+limit, args...)
 	if err != nil {
-		return users // Return empty slice instead of nil
-	}	
-	return users
+		return nil, err
+	}
+	return users, nil
 }
 
 // GetUserByID retrieves a user by their ID
 func GetUserByID(id int) (models.User, error) {
 	var user models.User
 	err := db.GetDB().Get(&user, `
-		SELECT id, username, email, role, banned, created_at
+		SELECT id, username, email, role, `+userBannedColumn+`, created_at, email_verified_at
 		FROM users 
 		WHERE id = $1`, id)
 
@@ -45,70 +60,240 @@ func GetUserByID(id int) (models.User, error) {
 	return user, nil
 }
 
-func BanUser(userID int) error {
-	_, err := db.GetDB().Exec("UPDATE users SET banned = true WHERE id = $1", userID)
+func DeleteConfessionByAdmin(confessionID int, actor models.Actor, reason string) error {
+	tx, err := db.GetDB().Beginx()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	return nil
-}
 
-func UnbanUser(userID int) error {
-	_, err := db.GetDB().Exec("UPDATE users SET banned = false WHERE id = $1", userID)
-	if err != nil {
+	if err := deleteConfessionByAdmin(tx, confessionID, actor, reason); err != nil {
+		tx.Rollback()
 		return err
 	}
+
+	// Commit the transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
-func DeleteConfessionByAdmin(confessionID int) error {
-	tx, err := db.GetDB().Begin()
+// deleteConfessionByAdmin deletes a confession. Its reports stay for the
+// record, the foreign key clears their confession_id.
+func deleteConfessionByAdmin(tx *sqlx.Tx, confessionID int, actor models.Actor, reason string) error {
+	return audited(tx, actor, models.AuditDeleteConfession, models.TargetConfession, strconv.Itoa(confessionID), reason, func() error {
+		result, err := tx.Exec("DELETE FROM confessions WHERE id = $1", confessionID)
+		if err != nil {
+			return fmt.Errorf("failed to delete confession: %w", err)
+		}
+		return expectRow(result, errs.ErrConfessionNotFound)
+	})
+}
+
+// hideConfession hides a confession on behalf of an admin
+func hideConfession(tx *sqlx.Tx, confessionID int, actor models.Actor, reason string) error {
+	return audited(tx, actor, models.AuditHideConfession, models.TargetConfession, strconv.Itoa(confessionID), reason, func() error {
+		// Hidden by an admin now, so it no longer waits in the auto-hidden queue
+		result, err := tx.Exec("UPDATE confessions SET status = $1, auto_hidden_at = NULL WHERE id = $2", models.ConfessionHidden, confessionID)
+		if err != nil {
+			return err
+		}
+		return expectRow(result, errs.ErrConfessionNotFound)
+	})
+}
+
+// UpdateReport moves a report to a new status. Resolving runs the resolution
+// action in the same transaction, so the report is only closed when the action
+// succeeded. Reopening clears the stored resolution but doesn't undo its action.
+func UpdateReport(reportID int, actor models.Actor, updateReq models.UpdateReport) error {
+	tx, err := db.GetDB().Beginx()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
-	// First delete all reports associated with this confession
-	_, err = tx.Exec("DELETE FROM reports WHERE confession_id = $1", confessionID)
-	if err != nil {
+	if err := updateReport(tx, reportID, actor, updateReq); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to delete reports: %w", err)
+		return err
 	}
 
-	// Then delete the confession
-	_, err = tx.Exec("DELETE FROM confessions WHERE id = $1", confessionID)
+	return tx.Commit()
+}
+
+func updateReport(tx *sqlx.Tx, reportID int, actor models.Actor, updateReq models.UpdateReport) error {
+	var report models.Report
+	err := tx.Get(&report, "SELECT * FROM reports WHERE id = $1 FOR UPDATE", reportID)
 	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to delete confession: %w", err)
+		return translateError(err)
 	}
 
-	// Commit the transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	status := *updateReq.Status
+	if !models.CanTransitionReport(report.Status, status) {
+		return errs.ErrInvalidReportTransition
 	}
 
-	return nil
-}
+	now := time.Now().UTC()
+	var action *string
+	var resolvedBy *int
+	var resolvedAt *time.Time
+	var note *string
+
+	switch status {
+	case models.ReportResolved, models.ReportDismissed:
+		act := models.ActionNone
+		if updateReq.Action != nil {
+			act = *updateReq.Action
+		}
+		action, resolvedBy, resolvedAt, note = &act, &actor.ID, &now, updateReq.Note
+	}
+
+	var reason string
+	if updateReq.Note != nil {
+		reason = *updateReq.Note
+	}
+
+	err = audited(tx, actor, models.AuditUpdateReport, models.TargetReport, strconv.Itoa(reportID), reason, func() error {
+		if action != nil {
+			if err := applyResolutionAction(tx, report, actor, *action, reason); err != nil {
+				return err
+			}
+		}
 
-func BanGuestUser(uuid string) error {
-	_, err := db.GetDB().Exec("UPDATE guest_users SET banned = true WHERE uuid = $1", uuid)
+		_, err := tx.Exec(`
+			UPDATE reports
+			SET status = $1, action = $2, resolved_by = $3, resolved_at = $4, note = $5, updated_at = $6
+			WHERE id = $7`,
+			status, action, resolvedBy, resolvedAt, note, now, reportID)
+		if err != nil {
+			return fmt.Errorf("failed to update report: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
+
+	// A confession hidden by reports comes back when they all turn out unfounded
+	if status == models.ReportDismissed && report.ConfessionID != nil && report.CommentID == nil {
+		if err := restoreAutoHidden(tx, *report.ConfessionID, actor, reason); err != nil {
+			return fmt.Errorf("failed to restore confession: %w", err)
+		}
+	}
+
 	return nil
 }
 
-func UnbanGuestUser(uuid string) error {
-	_, err := db.GetDB().Exec("UPDATE guest_users SET banned = false WHERE uuid = $1", uuid)
+// applyResolutionAction carries out the action a report is resolved with.
+// It acts on the reported comment if there is one, otherwise on the confession.
+// The change to the affected user, guest or content gets its own audit entry.
+func applyResolutionAction(tx *sqlx.Tx, report models.Report, actor models.Actor, action string, reason string) error {
+	if action == models.ActionNone {
+		return nil
+	}
+
+	var author struct {
+		UserID    *int    `db:"user_id"`
+		GuestUUID *string `db:"guest_uuid"`
+	}
+	var err error
+	switch {
+	case report.CommentID != nil:
+		err = tx.Get(&author, "SELECT user_id, guest_uuid FROM comments WHERE id = $1", *report.CommentID)
+	case report.ConfessionID != nil:
+		err = tx.Get(&author, "SELECT user_id, guest_uuid FROM confessions WHERE id = $1", *report.ConfessionID)
+	default:
+		err = sql.ErrNoRows
+	}
+	if err == sql.ErrNoRows {
+		return errs.ErrReportedContentGone
+	}
 	if err != nil {
 		return err
 	}
+
+	switch action {
+	case models.ActionHideConfession:
+		if report.CommentID != nil {
+			commentID := *report.CommentID
+			err = audited(tx, actor, models.AuditRemoveComment, models.TargetComment, strconv.Itoa(commentID), reason, func() error {
+				_, err := tx.Exec("UPDATE comments SET status = $1, updated_at = $2 WHERE id = $3",
+					models.CommentRemoved, time.Now().UTC(), commentID)
+				return err
+			})
+		} else {
+			err = hideConfession(tx, *report.ConfessionID, actor, reason)
+		}
+
+	case models.ActionDeleteConfession:
+		if report.CommentID != nil {
+			// Replies by other people stay, so the comment becomes an empty placeholder
+			commentID := *report.CommentID
+			err = audited(tx, actor, models.AuditRemoveComment, models.TargetComment, strconv.Itoa(commentID), reason, func() error {
+				_, err := tx.Exec("UPDATE comments SET status = $1, text = '', updated_at = $2 WHERE id = $3",
+					models.CommentRemoved, time.Now().UTC(), commentID)
+				return err
+			})
+		} else {
+			confessionID := *report.ConfessionID
+			err = audited(tx, actor, models.AuditDeleteConfession, models.TargetConfession, strconv.Itoa(confessionID), reason, func() error {
+				_, err := tx.Exec("DELETE FROM confessions WHERE id = $1", confessionID)
+				return err
+			})
+		}
+
+	case models.ActionBanAuthor:
+		if author.UserID == nil {
+			return errs.ErrActionTargetMismatch
+		}
+		userID := *author.UserID
+		if err := checkBannableUser(tx, userID, actor); err != nil {
+			return err
+		}
+		err = audited(tx, actor, models.AuditBanUser, models.TargetUser, strconv.Itoa(userID), reason, func() error {
+			// An author who is banned already stays banned
+			err := insertBan(tx, models.Ban{UserID: &userID, Scope: models.BanScopeLogin, Reason: reason, IssuedBy: &actor.ID})
+			if errors.Is(err, errs.ErrUserAlreadyBanned) {
+				return nil
+			}
+			return err
+		})
+
+	case models.ActionBanGuest:
+		if author.GuestUUID == nil {
+			return errs.ErrActionTargetMismatch
+		}
+		guestUUID := *author.GuestUUID
+		err = audited(tx, actor, models.AuditBanGuest, models.TargetGuest, guestUUID, reason, func() error {
+			// An author who is banned already stays banned
+			err := insertBan(tx, models.Ban{GuestUUID: &guestUUID, Scope: models.BanScopeLogin, Reason: reason, IssuedBy: &actor.ID})
+			if errors.Is(err, errs.ErrUserAlreadyBanned) {
+				return nil
+			}
+			return err
+		})
+
+	default:
+		return errs.ErrInvalidResolutionAction
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply resolution action %s: %w", action, err)
+	}
+
 	return nil
 }
 
-func UpdateReport(reportID int, updateReq models.UpdateReport) error {
-	_, err := db.GetDB().Exec("UPDATE reports SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", updateReq.Status, reportID)
-	if err != nil {
-		return err
+// checkBannableUser refuses to ban the actor or a user whose role isn't
+// below the role of the actor
+func checkBannableUser(tx *sqlx.Tx, userID int, actor models.Actor) error {
+	if userID == actor.ID {
+		return errs.ErrYouCannotBanYourself
+	}
+	var role string
+	if err := tx.Get(&role, "SELECT role FROM users WHERE id = $1", userID); err != nil {
+		return translateError(err)
+	}
+	if !models.Outranks(actor.Role, role) {
+		return errs.ErrOutranked
 	}
 	return nil
 }
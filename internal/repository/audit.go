@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// snapshotQueries select the fields of each target type that go into the
+// before/after snapshots of the audit log
+var snapshotQueries = map[string]string{
+	models.TargetUser:       "SELECT id, username, email, role, " + activeBansColumn("user_id", "users.id") + " FROM users WHERE id = $1::int",
+	models.TargetGuest:      "SELECT uuid, merged_into, " + activeBansColumn("guest_uuid", "guest_users.uuid") + " FROM guest_users WHERE uuid = $1::uuid",
+	models.TargetConfession: "SELECT id, user_id, guest_uuid, username, title, text, anon, status, moderation_reason, created_at FROM confessions WHERE id = $1::int",
+	models.TargetComment:    "SELECT id, confession_id, parent_id, user_id, guest_uuid, username, anon, alias, text, status FROM comments WHERE id = $1::int",
+	models.TargetReport:     "SELECT id, user_id, confession_id, comment_id, reason, status, action, resolved_by, note, resolved_at FROM reports WHERE id = $1::int",
+	models.TargetFilter:     "SELECT id, pattern, kind, action, enabled FROM content_filters WHERE id = $1::int",
+	models.TargetLockout:    "SELECT id, scope, key, user_id, failures, last_failure_at, blocked_until, locked_at FROM login_failures WHERE id = $1::int",
+}
+
+// activeBansColumn lists the bans in force in a user or guest snapshot
+func activeBansColumn(banColumn, idColumn string) string {
+	return `(SELECT COALESCE(jsonb_agg(jsonb_build_object('id', b.id, 'scope', b.scope, 'expires_at', b.expires_at)), '[]')
+		FROM bans b WHERE b.` + banColumn + ` = ` + idColumn + ` AND ` + activeBanCond + `) AS active_bans`
+}
+
+// audited runs mutate inside tx and records it in the audit log together
+// with snapshots of the target taken before and after
+func audited(tx *sqlx.Tx, actor models.Actor, action, targetType, targetID, reason string, mutate func() error) error {
+	before, err := snapshot(tx, targetType, targetID)
+	if err != nil {
+		return err
+	}
+
+	if err := mutate(); err != nil {
+		return err
+	}
+
+	after, err := snapshot(tx, targetType, targetID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO moderation_actions (actor_id, action, target_type, target_id, reason, before, after, ip, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		actor.ID, action, targetType, targetID, reason, string(before), string(after), actor.IP, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+func snapshot(tx *sqlx.Tx, targetType, targetID string) (json.RawMessage, error) {
+	var raw []byte
+	err := tx.Get(&raw, "SELECT to_jsonb(t) FROM ("+snapshotQueries[targetType]+") t", targetID)
+	if err == sql.ErrNoRows {
+		return json.RawMessage("null"), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot %s %s: %w", targetType, targetID, err)
+	}
+	return raw, nil
+}
+
+// GetModerationActions returns one page of the audit log
+func GetModerationActions(filter models.AuditFilter, page models.PageQuery) ([]models.ModerationAction, error) {
+	actions := make([]models.ModerationAction, 0)
+
+	where, args := auditConditions(filter)
+	cond, order, keysetArgs := keyset(page, "id", len(args)+1)
+	args = append(args, keysetArgs...)
+	limit, limitArg := limitClause(page, len(args)+1)
+	args = append(args, limitArg)
+
+	query := `
+		SELECT * FROM (
+			SELECT m.*, u.username AS actor_username
+			FROM moderation_actions m
+			LEFT JOIN users u ON u.id = m.actor_id
+			WHERE ` + where + `
+		) a
+		WHERE ` + cond + `
+		` + order + `
+		` + limit
+
+	if err := db.GetDB().Select(&actions, query, args...); err != nil {
+		return nil, err
+	}
+	return actions, nil
+}
+
+// ExportModerationActions calls fn for every audit log entry matching the
+// filter, oldest first, without loading them all into memory
+func ExportModerationActions(filter models.AuditFilter, fn func(models.ModerationAction) error) error {
+	where, args := auditConditions(filter)
+
+	rows, err := db.GetDB().Queryx(`
+		SELECT m.*, u.username AS actor_username
+		FROM moderation_actions m
+		LEFT JOIN users u ON u.id = m.actor_id
+		WHERE `+where+`
+		ORDER BY m.created_at, m.id`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var action models.ModerationAction
+		if err := rows.StructScan(&action); err != nil {
+			return err
+		}
+		if err := fn(action); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func auditConditions(filter models.AuditFilter) (string, []interface{}) {
+	conds := []string{"TRUE"}
+	var args []interface{}
+
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.ActorID != 0 {
+		add("m.actor_id = $%d", filter.ActorID)
+	}
+	if filter.TargetType != "" {
+		add("m.target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		add("m.target_id = $%d", filter.TargetID)
+	}
+	if filter.From != nil {
+		add("m.created_at >= $%d", filter.From.UTC())
+	}
+	if filter.To != nil {
+		add("m.created_at < $%d", filter.To.UTC())
+	}
+
+	return strings.Join(conds, " AND "), args
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// activeBanCond matches the bans, aliased b, that are currently in force
+const activeBanCond = `b.lifted_at IS NULL AND (b.expires_at IS NULL OR b.expires_at > (NOW() AT TIME ZONE 'UTC'))`
+
+// Computed banned columns for users and guest_users: set while a login ban is in force
+const (
+	userBannedColumn  = `EXISTS(SELECT 1 FROM bans b WHERE b.user_id = users.id AND b.scope = 'login' AND ` + activeBanCond + `) AS banned`
+	guestBannedColumn = `EXISTS(SELECT 1 FROM bans b WHERE b.guest_uuid = guest_users.uuid AND b.scope = 'login' AND ` + activeBanCond + `) AS banned`
+)
+
+const banColumns = `b.id, b.user_id, b.guest_uuid, b.scope, b.reason, b.issued_by, b.expires_at,
+	b.created_at, b.lifted_at, b.lifted_by, (` + activeBanCond + `) AS active`
+
+// GetActiveBan returns the ban in force against a user or guest that blocks
+// one of the scopes, preferring login bans and then the longest one.
+// It returns nil when there is none.
+func GetActiveBan(userID *int, guestUUID *string, scopes []string) (*models.Ban, error) {
+	var ban models.Ban
+	err := db.GetDB().Get(&ban, `
+		SELECT `+banColumns+`
+		FROM bans b
+		WHERE (b.user_id = $1 OR b.guest_uuid = $2)
+		  AND b.scope = ANY($3)
+		  AND `+activeBanCond+`
+		ORDER BY b.scope = 'login' DESC, b.expires_at DESC NULLS FIRST
+		LIMIT 1`, userID, guestUUID, pq.Array(scopes))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
+
+// GetBans returns the ban history of a user or guest, newest first
+func GetBans(userID *int, guestUUID *string) ([]models.Ban, error) {
+	bans := make([]models.Ban, 0)
+	err := db.GetDB().Select(&bans, `
+		SELECT `+banColumns+`
+		FROM bans b
+		WHERE b.user_id = $1 OR b.guest_uuid = $2
+		ORDER BY b.created_at DESC, b.id DESC`, userID, guestUUID)
+	if err != nil {
+		return nil, err
+	}
+	return bans, nil
+}
+
+// CreateBan bans a user or guest on behalf of an admin. It fails with
+// ErrUserAlreadyBanned if a ban with the same scope is already in force.
+func CreateBan(ban models.Ban, actor models.Actor) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err := createBan(tx, ban, actor); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func createBan(tx *sqlx.Tx, ban models.Ban, actor models.Actor) error {
+	targetType, targetID := banTarget(ban.UserID, ban.GuestUUID)
+	action := models.AuditBanUser
+	if ban.GuestUUID != nil {
+		action = models.AuditBanGuest
+	}
+
+	if ban.UserID != nil {
+		if err := checkBannableUser(tx, *ban.UserID, actor); err != nil {
+			return err
+		}
+	}
+
+	return audited(tx, actor, action, targetType, targetID, ban.Reason, func() error {
+		return insertBan(tx, ban)
+	})
+}
+
+// LiftBans lifts every ban in force against a user or guest. It fails with
+// ErrUserNotBanned if there is none.
+func LiftBans(userID *int, guestUUID *string, actor models.Actor, reason string) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	targetType, targetID := banTarget(userID, guestUUID)
+	action := models.AuditUnbanUser
+	if guestUUID != nil {
+		action = models.AuditUnbanGuest
+	}
+
+	err = audited(tx, actor, action, targetType, targetID, reason, func() error {
+		result, err := tx.Exec(`
+			UPDATE bans b SET lifted_at = $1, lifted_by = $2
+			WHERE (b.user_id = $3 OR b.guest_uuid = $4) AND `+activeBanCond,
+			time.Now().UTC(), actor.ID, userID, guestUUID)
+		if err != nil {
+			return err
+		}
+		return expectRow(result, errs.ErrUserNotBanned)
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertBan(tx *sqlx.Tx, ban models.Ban) error {
+	var exists bool
+	err := tx.Get(&exists, `
+		SELECT EXISTS(
+			SELECT 1 FROM bans b
+			WHERE (b.user_id = $1 OR b.guest_uuid = $2) AND b.scope = $3 AND `+activeBanCond+`
+		)`, ban.UserID, ban.GuestUUID, ban.Scope)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errs.ErrUserAlreadyBanned
+	}
+
+	var expiresAt *time.Time
+	if ban.ExpiresAt != nil {
+		utc := ban.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO bans (user_id, guest_uuid, scope, reason, issued_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		ban.UserID, ban.GuestUUID, ban.Scope, ban.Reason, ban.IssuedBy, expiresAt, time.Now().UTC())
+	return err
+}
+
+func banTarget(userID *int, guestUUID *string) (string, string) {
+	if userID != nil {
+		return models.TargetUser, strconv.Itoa(*userID)
+	}
+	return models.TargetGuest, *guestUUID
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// OwnerExists reports whether any user has the owner role
+func OwnerExists() (bool, error) {
+	var exists bool
+	err := db.GetDB().Get(&exists, "SELECT EXISTS (SELECT 1 FROM users WHERE role = $1)", models.RoleOwner)
+	return exists, err
+}
+
+// CreateBootstrapToken stores a new bootstrap token unless an unused one is
+// still valid, and reports whether it did. It holds the migration lock, so of
+// several replicas starting together only the first one issues a token and
+// the others leave it working.
+func CreateBootstrapToken(tokenHash string, expiresAt time.Time) (bool, error) {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return false, err
+	}
+
+	if err := db.LockMigrationsTx(tx); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	now := time.Now().UTC()
+	var valid bool
+	err = tx.Get(&valid, "SELECT EXISTS (SELECT 1 FROM bootstrap_tokens WHERE used_at IS NULL AND expires_at > $1)", now)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if valid {
+		return false, tx.Commit()
+	}
+
+	if _, err := tx.Exec("DELETE FROM bootstrap_tokens WHERE used_at IS NULL"); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	_, err = tx.Exec("INSERT INTO bootstrap_tokens (token_hash, expires_at, created_at) VALUES ($1, $2, $3)",
+		tokenHash, expiresAt, now)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// CompleteBootstrap uses up a bootstrap token and creates the first owner.
+// The token stops working once there is an owner, however it came to be.
+func CompleteBootstrap(tokenHash string, user models.User) (int, error) {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return 0, err
+	}
+
+	var tokenID int
+	err = tx.Get(&tokenID, `
+		UPDATE bootstrap_tokens SET used_at = $1
+		WHERE token_hash = $2 AND used_at IS NULL AND expires_at > $1
+		RETURNING id`,
+		time.Now().UTC(), tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return 0, errs.ErrInvalidBootstrapToken
+	}
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	var ownerExists bool
+	if err := tx.Get(&ownerExists, "SELECT EXISTS (SELECT 1 FROM users WHERE role = $1)", models.RoleOwner); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if ownerExists {
+		tx.Rollback()
+		return 0, errs.ErrInvalidBootstrapToken
+	}
+
+	userID, err := insertStaffUser(tx, user)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+// CreateStaffUser stores a user with a role other than user. The email
+// counts as verified, the person creating the account vouches for it.
+func CreateStaffUser(user models.User) (int, error) {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return 0, err
+	}
+
+	userID, err := insertStaffUser(tx, user)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+func insertStaffUser(tx *sqlx.Tx, user models.User) (int, error) {
+	var taken bool
+	if err := tx.Get(&taken, "SELECT EXISTS (SELECT 1 FROM users WHERE username = $1)", user.Username); err != nil {
+		return 0, err
+	}
+	if taken {
+		return 0, errs.ErrUserAlreadyExists
+	}
+
+	var userID int
+	err := tx.QueryRow(`
+		INSERT INTO users (username, email, password, role, email_verified_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		RETURNING id`,
+		user.Username, user.Email, user.Password, user.Role, time.Now().UTC()).Scan(&userID)
+	return userID, err
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// FindConfessionIDs lists up to limit confessions selected by a filter,
+// oldest first
+func FindConfessionIDs(filter models.ConfessionFilter, limit int) ([]int, error) {
+	ids := make([]int, 0)
+	err := db.GetDB().Select(&ids, `
+		SELECT id
+		FROM confessions
+		WHERE ($1::int IS NULL OR user_id = $1)
+			AND ($2::uuid IS NULL OR guest_uuid = $2)
+			AND ($3::timestamp IS NULL OR created_at >= $3)
+			AND ($4::timestamp IS NULL OR created_at < $4)
+		ORDER BY created_at, id
+		LIMIT $5`,
+		filter.UserID, filter.GuestUUID, filter.Since, filter.Until, limit)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// RunBulkOperations carries out prepared bulk operations and returns their
+// results in order. Each operation runs in its own transaction, or with
+// atomic all of them run in one and the first failure rolls back the rest.
+func RunBulkOperations(ops []models.BulkOperation, actor models.Actor, atomic bool) ([]models.BulkResult, error) {
+	results := make([]models.BulkResult, len(ops))
+	for i, op := range ops {
+		results[i] = models.BulkResult{Index: i, Action: op.Action, ConfessionIDs: op.ConfessionIDs}
+	}
+
+	if !atomic {
+		for i, op := range ops {
+			tx, err := db.GetDB().Beginx()
+			if err != nil {
+				return nil, err
+			}
+			if err := runBulkOperation(tx, op, actor); err != nil {
+				tx.Rollback()
+				results[i].Status, results[i].Error = models.BulkFailed, err.Error()
+				continue
+			}
+			if err := tx.Commit(); err != nil {
+				results[i].Status, results[i].Error = models.BulkFailed, err.Error()
+				continue
+			}
+			results[i].Status = models.BulkOK
+		}
+		return results, nil
+	}
+
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	failed := -1
+	for i, op := range ops {
+		if err := runBulkOperation(tx, op, actor); err != nil {
+			failed = i
+			results[i].Status, results[i].Error = models.BulkFailed, err.Error()
+			break
+		}
+		results[i].Status = models.BulkOK
+	}
+	if failed < 0 {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return results, nil
+	}
+
+	tx.Rollback()
+	for i := range results {
+		switch {
+		case i < failed:
+			results[i].Status = models.BulkRolledBack
+		case i > failed:
+			results[i].Status = models.BulkSkipped
+		}
+	}
+	return results, nil
+}
+
+func runBulkOperation(tx *sqlx.Tx, op models.BulkOperation, actor models.Actor) error {
+	switch op.Action {
+	case models.BulkDeleteConfession:
+		for _, id := range op.ConfessionIDs {
+			if err := deleteConfessionByAdmin(tx, id, actor, op.Reason); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case models.BulkHideConfession:
+		for _, id := range op.ConfessionIDs {
+			if err := hideConfession(tx, id, actor, op.Reason); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case models.BulkBanUser:
+		return banIfNotBanned(tx, op.Ban, actor)
+
+	case models.BulkBanGuest:
+		var exists bool
+		err := tx.Get(&exists, "SELECT EXISTS(SELECT 1 FROM guest_users WHERE uuid = $1)", *op.Ban.GuestUUID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errs.ErrNotFound
+		}
+		return banIfNotBanned(tx, op.Ban, actor)
+
+	case models.BulkResolveReport:
+		return updateReport(tx, *op.ReportID, actor, op.ReportUpdate)
+
+	default:
+		return errs.ErrInvalidBulkAction
+	}
+}
+
+// banIfNotBanned bans a user or guest. Someone caught by several operations
+// of a cleanup is banned once and the rest succeed without a change.
+func banIfNotBanned(tx *sqlx.Tx, ban models.Ban, actor models.Actor) error {
+	err := createBan(tx, ban, actor)
+	if errors.Is(err, errs.ErrUserAlreadyBanned) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetGuestPostingStats counts the recent guest confessions overall and the
+// recent and hidden or rejected confessions of one guest
+func GetGuestPostingStats(guestUUID string, since time.Time) (models.GuestPostingStats, error) {
+	var stats models.GuestPostingStats
+	err := db.GetDB().Get(&stats, `
+		SELECT
+			(SELECT COUNT(*) FROM confessions WHERE guest_uuid IS NOT NULL AND created_at >= $2) AS guest_volume,
+			COUNT(*) FILTER (WHERE created_at >= $2) AS recent,
+			COUNT(*) FILTER (WHERE status IN ('hidden', 'rejected')) AS hidden
+		FROM confessions
+		WHERE guest_uuid = $1`, guestUUID, since)
+	if err != nil {
+		return models.GuestPostingStats{}, err
+	}
+	return stats, nil
+}
+
+// useChallenge spends the nonce of a solved challenge. It fails with
+// ErrChallengeUsed if the nonce has been spent before.
+func useChallenge(tx *sqlx.Tx, challenge models.SolvedChallenge) error {
+	_, err := tx.Exec("DELETE FROM used_challenges WHERE expires_at < $1", time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO used_challenges (nonce, expires_at) VALUES ($1, $2)
+		ON CONFLICT (nonce) DO NOTHING`, challenge.Nonce, challenge.ExpiresAt.UTC())
+	if err != nil {
+		return err
+	}
+	return expectRow(result, errs.ErrChallengeUsed)
+}
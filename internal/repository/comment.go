@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+)
+
+const commentColumns = `
+	id, confession_id, parent_id, depth, user_id, guest_uuid, username,
+	anon, alias, text, status, created_at, updated_at`
+
+// CreateComment stores a comment and returns its id. Anonymous comments get
+// the commenter's alias in the thread, which is created on the first
+// anonymous comment from animal and the next free number in the thread.
+func CreateComment(comment models.Comment, identity string, animal string) (int, error) {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	// Locking the confession serializes alias numbering within the thread
+	var confessionID int
+	err = tx.QueryRow("SELECT id FROM confessions WHERE id = $1 FOR UPDATE", comment.ConfessionID).Scan(&confessionID)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return 0, errs.ErrConfessionNotFound
+		}
+		return 0, err
+	}
+
+	var alias *string
+	if comment.Anon {
+		var existing string
+		err = tx.QueryRow(`
+			SELECT alias FROM comment_aliases
+			WHERE confession_id = $1 AND identity = $2`, confessionID, identity).Scan(&existing)
+		if err == sql.ErrNoRows {
+			var number int
+			err = tx.QueryRow(`
+				SELECT COALESCE(MAX(number), 0) + 1 FROM comment_aliases
+				WHERE confession_id = $1`, confessionID).Scan(&number)
+			if err != nil {
+				tx.Rollback()
+				return 0, err
+			}
+
+			existing = fmt.Sprintf(models.CommentAliasFormat, animal, number)
+			_, err = tx.Exec(`
+				INSERT INTO comment_aliases (confession_id, identity, number, alias)
+				VALUES ($1, $2, $3, $4)`, confessionID, identity, number, existing)
+		}
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to assign comment alias: %w", err)
+		}
+		alias = &existing
+	}
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO comments (confession_id, parent_id, depth, user_id, guest_uuid, username, anon, alias, text, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`,
+		confessionID,
+		comment.ParentID,
+		comment.Depth,
+		comment.UserID,
+		comment.GuestUUID,
+		comment.Username,
+		comment.Anon,
+		alias,
+		comment.Text,
+		time.Now().UTC()).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetComment retrieves a single comment by ID
+func GetComment(id int) (models.Comment, error) {
+	var comment models.Comment
+	err := db.GetDB().Get(&comment, "SELECT "+commentColumns+" FROM comments WHERE id = $1", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Comment{}, errs.ErrCommentNotFound
+		}
+		return models.Comment{}, err
+	}
+	return comment, nil
+}
+
+// GetComments returns all comments on a confession, oldest first
+func GetComments(confessionID int) ([]*models.Comment, error) {
+	comments := make([]*models.Comment, 0)
+	err := db.GetDB().Select(&comments, `
+		SELECT `+commentColumns+`
+		FROM comments
+		WHERE confession_id = $1
+		ORDER BY created_at, id`, confessionID)
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// UpdateCommentText replaces the text of a visible comment
+func UpdateCommentText(id int, text string) error {
+	result, err := db.GetDB().Exec(`
+		UPDATE comments SET text = $1, updated_at = $2
+		WHERE id = $3 AND status = $4`, text, time.Now().UTC(), id, models.CommentVisible)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, errs.ErrCommentNotFound)
+}
+
+// DeleteComment removes a comment for its author. A comment that has replies
+// is kept as a placeholder without text so the thread stays intact.
+func DeleteComment(id int) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+
+	var hasReplies bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM comments WHERE parent_id = $1)", id).Scan(&hasReplies)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if hasReplies {
+		_, err = tx.Exec(`
+			UPDATE comments SET text = '', status = $1, updated_at = $2
+			WHERE id = $3`, models.CommentDeleted, time.Now().UTC(), id)
+	} else {
+		_, err = tx.Exec("DELETE FROM comments WHERE id = $1", id)
+	}
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// RemoveComment hides a comment by an administrator. The text is kept for
+// moderation purposes.
+func RemoveComment(id int, actor models.Actor, reason string) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	err = audited(tx, actor, models.AuditRemoveComment, models.TargetComment, strconv.Itoa(id), reason, func() error {
+		result, err := tx.Exec(`
+			UPDATE comments SET status = $1, updated_at = $2
+			WHERE id = $3`, models.CommentRemoved, time.Now().UTC(), id)
+		if err != nil {
+			return err
+		}
+		return expectRow(result, errs.ErrCommentNotFound)
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// expectRow returns notFound when the statement did not touch any row
+func expectRow(result sql.Result, notFound error) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return notFound
+	}
+	return nil
+}
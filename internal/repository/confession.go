@@ -1,19 +1,29 @@
 package repository
 
 import (
+	"github.com/hadisjane/confessly/internal/configs"
 	"github.com/hadisjane/confessly/internal/db"
 	"github.com/hadisjane/confessly/internal/errs"
 	"github.com/hadisjane/confessly/internal/models"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 )
 
-// CreateConfession creates a new confession in the database
-func CreateConfession(confession models.Confession) error {
-	tx, err := db.GetDB().Begin()
+// CreateConfession creates a new confession in the database and returns its
+// id. A guest's challenge is spent in the same transaction.
+func CreateConfession(confession models.Confession, challenge *models.SolvedChallenge) (int, error) {
+	tx, err := db.GetDB().Beginx()
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	if challenge != nil {
+		if err := useChallenge(tx, *challenge); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
 	}
 
 	query := `
@@ -24,10 +34,12 @@ func CreateConfession(confession models.Confession) error {
 			title, 
 			text, 
 			anon, 
+			status, 
+			fingerprint, 
 			created_at, 
 			updated_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id
 	`
 
@@ -46,7 +58,7 @@ func CreateConfession(confession models.Confession) error {
 		guestUUID = nil
 	}
 
-	now := time.Now()
+	now := time.Now().UTC()
 	err = tx.QueryRow(
 		query,
 		userID,
@@ -55,21 +67,30 @@ func CreateConfession(confession models.Confession) error {
 		confession.Title,
 		confession.Text,
 		confession.Anon,
+		confession.Status,
+		confession.Fingerprint,
 		now,
 		now,
 	).Scan(&confession.ID)
 
 	if err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to create confession: %w", err)
+		return 0, fmt.Errorf("failed to create confession: %w", err)
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return confession.ID, nil
 }
 
-// GetAllConfessions retrieves all confessions from the database
-func GetAllConfessions() ([]models.Confession, error) {
-	var confessions []models.Confession
+// GetAllConfessions retrieves one page of confessions from the database
+func GetAllConfessions(page models.PageQuery) ([]models.Confession, error) {
+	confessions := make([]models.Confession, 0)
+
+	cond, order, args := keyset(page, "id", 1)
+	limit, limitArg := limitClause(page, len(args)+1)
+	args = append(args, limitArg)
 
 	query := `
 		SELECT 
@@ -80,17 +101,54 @@ func GetAllConfessions() ([]models.Confession, error) {
 			title, 
 			text, 
 			anon, 
+			status, 
 			created_at, 
 			updated_at
 		FROM confessions
-		ORDER BY created_at DESC
-	`
+		WHERE status = 'published' AND ` + cond + `
+		` + order + `
+		` + limit
 
-	err := db.GetDB().Select(&confessions, query)
+	err := db.GetDB().Select(&confessions, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return confessions, nil
+}
+
+// GetOwnConfessions retrieves one page of the confessions of a user or guest
+// in any status, optionally only those with the given status
+func GetOwnConfessions(userID *int, guestUUID *string, status string, page models.PageQuery) ([]models.Confession, error) {
+	confessions := make([]models.Confession, 0)
+
+	cond, order, args := keyset(page, "id", 4)
+	limit, limitArg := limitClause(page, len(args)+4)
+	args = append([]interface{}{userID, guestUUID, status}, args...)
+	args = append(args, limitArg)
+
+	query := `
+		SELECT 
+			id, 
+			user_id, 
+			guest_uuid, 
+			username, 
+			title, 
+			text, 
+			anon, 
+			status, 
+			moderation_reason, 
+			created_at, 
+			updated_at
+		FROM confessions
+		WHERE (user_id = $1 OR guest_uuid = $2)
+		  AND ($3 = '' OR status = $3)
+		  AND ` + cond + `
+		` + order + `
+		` + limit
+
+	err := db.GetDB().Select(&confessions, query, args...)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return []models.Confession{}, nil
-		}
 		return nil, err
 	}
 
@@ -110,6 +168,8 @@ func GetConfession(id int) (models.Confession, error) {
 			title, 
 			text, 
 			anon, 
+			status, 
+			moderation_reason, 
 			created_at, 
 			updated_at
 		FROM confessions
@@ -140,8 +200,12 @@ func UpdateConfession(id int, confession models.Confession) error {
 			title = $1, 
 			text = $2, 
 			anon = $3,
-			updated_at = $4
-		WHERE id = $5
+			status = $4,
+			-- A rejection reason only makes sense while the confession stays rejected
+			moderation_reason = CASE WHEN status = $4 THEN moderation_reason END,
+			fingerprint = $5,
+			updated_at = $6
+		WHERE id = $7
 		RETURNING id
 	`
 
@@ -151,7 +215,9 @@ func UpdateConfession(id int, confession models.Confession) error {
 		confession.Title,
 		confession.Text,
 		confession.Anon,
-		time.Now(),
+		confession.Status,
+		confession.Fingerprint,
+		time.Now().UTC(),
 		id,
 	).Scan(&updatedID)
 
@@ -192,36 +258,81 @@ func DeleteConfession(id int) error {
 	return tx.Commit()
 }
 
-// SearchConfessionsByTitle searches confessions by title
-func SearchConfessionsByTitle(searchQuery string) ([]models.Confession, error) {
+// SearchConfessions runs a ranked full-text search over confession titles and text.
+// The query uses websearch syntax: "quoted phrases", -exclusions and OR.
+func SearchConfessions(searchQuery string, page models.PageQuery) ([]models.ConfessionSearchResult, error) {
+	results := make([]models.ConfessionSearchResult, 0)
+
+	languages := db.SearchLanguages()
+	titleOpts, snippetOpts := headlineOptions()
+
+	var cond, order string
+	var args []interface{}
+	if page.Sort == models.SortRelevance {
+		cond, order, args = rankKeyset(page, 4)
+	} else {
+		cond, order, args = keyset(page, "id", 4)
+	}
+	limit, limitArg := limitClause(page, len(args)+4)
+	args = append([]interface{}{searchQuery, titleOpts, snippetOpts}, args...)
+	args = append(args, limitArg)
+
+	// Headlines are expensive, so they are only built for the rows on the page
 	query := `
+		WITH q AS (
+			SELECT ` + db.SearchQueryExpr(languages, "$1") + ` AS query
+		),
+		matches AS (
+			SELECT 
+				c.id, 
+				c.user_id, 
+				c.guest_uuid, 
+				c.username, 
+				c.title, 
+				c.text, 
+				c.anon, 
+				c.status, 
+				c.created_at, 
+				c.updated_at,
+				ts_rank_cd(c.search_vector, q.query)::float8 AS rank
+			FROM confessions c, q
+			WHERE c.search_vector @@ q.query AND c.status = 'published'
+		),
+		page AS (
+			SELECT * FROM matches
+			WHERE ` + cond + `
+			` + order + `
+			` + limit + `
+		)
 		SELECT 
-			id, 
-			user_id, 
-			guest_uuid, 
-			username, 
-			title, 
-			text, 
-			anon, 
-			created_at, 
-			updated_at
-		FROM confessions
-		WHERE title ILIKE $1
-		ORDER BY created_at DESC
-		LIMIT 100
-	`
-
-	var confessions []models.Confession
-	searchTerm := "%" + searchQuery + "%"
-	
+			page.*,
+			ts_headline('` + languages[0] + `'::regconfig, ` + htmlEscaped("page.title") + `, q.query, $2) AS title_highlight,
+			ts_headline('` + languages[0] + `'::regconfig, ` + htmlEscaped("page.text") + `, q.query, $3) AS snippet
+		FROM page, q
+		` + order
 
-	err := db.GetDB().Select(&confessions, query, searchTerm)
+	err := db.GetDB().Select(&results, query, args...)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return []models.Confession{}, nil
-		}
 		return nil, fmt.Errorf("failed to search confessions: %w", err)
 	}
 
-	return confessions, nil
-}
\ No newline at end of file
+	return results, nil
+}
+
+// htmlEscaped escapes a text column for HTML. Highlights are meant to be
+// rendered as markup, so the user's text must not carry any of its own.
+func htmlEscaped(column string) string {
+	return `replace(replace(replace(replace(replace(` + column +
+		`, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+}
+
+// headlineOptions returns the ts_headline options for titles and snippets
+func headlineOptions() (string, string) {
+	params := configs.AppSettings.SearchParams
+	start := strings.ReplaceAll(params.HighlightStart, `"`, "")
+	stop := strings.ReplaceAll(params.HighlightStop, `"`, "")
+
+	sel := fmt.Sprintf(`StartSel="%s", StopSel="%s"`, start, stop)
+	return sel + ", HighlightAll=true",
+		sel + `, MaxFragments=3, MaxWords=35, MinWords=15, FragmentDelimiter=" ... "`
+}
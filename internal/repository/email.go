@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetUserByEmail finds a user by email, ignoring case
+func GetUserByEmail(email string) (models.User, error) {
+	var user models.User
+	err := db.GetDB().Get(&user, `
+		SELECT id, username, email, role, created_at, email_verified_at
+		FROM users
+		WHERE lower(email) = lower($1)
+		ORDER BY id
+		LIMIT 1`, email)
+	if err != nil {
+		return models.User{}, translateError(err)
+	}
+	return user, nil
+}
+
+// CreateEmailToken stores a token sent by email. Tokens for the same purpose
+// that the user hasn't used yet are dropped, so only the latest email works.
+func CreateEmailToken(token models.EmailToken) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM email_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL",
+		token.UserID, token.Purpose)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO email_tokens (user_id, purpose, email, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		token.UserID, token.Purpose, token.Email, token.TokenHash, token.ExpiresAt, time.Now().UTC())
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// VerifyEmail uses up a verification token and marks the address it was sent
+// to as verified
+func VerifyEmail(tokenHash string) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	token, err := consumeEmailToken(tx, tokenHash, models.EmailTokenVerify)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, $1)
+		WHERE id = $2 AND email = $3`,
+		time.Now().UTC(), token.UserID, token.Email)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	// The address changed since the token was sent
+	if err := expectRow(result, errs.ErrInvalidEmailToken); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ResetPassword uses up a reset token, sets the new password hash and signs
+// the user out everywhere. Receiving the email proves the address, so it
+// counts as verified too.
+func ResetPassword(tokenHash string, passwordHash string) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	token, err := consumeEmailToken(tx, tokenHash, models.EmailTokenReset)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET password = $1,
+			password_change_required = FALSE,
+			email_verified_at = COALESCE(email_verified_at, CASE WHEN email = $2 THEN $3::timestamp END)
+		WHERE id = $4`,
+		passwordHash, token.Email, time.Now().UTC(), token.UserID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := revokeAllUserTokens(tx, token.UserID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// consumeEmailToken marks an unused, unexpired token as used and returns it.
+// Any other token is ErrInvalidEmailToken.
+func consumeEmailToken(tx *sqlx.Tx, tokenHash string, purpose string) (models.EmailToken, error) {
+	var token models.EmailToken
+	err := tx.Get(&token, `
+		UPDATE email_tokens SET used_at = $1
+		WHERE token_hash = $2 AND purpose = $3 AND used_at IS NULL AND expires_at > $1
+		RETURNING id, user_id, purpose, email, token_hash, expires_at, used_at, created_at`,
+		time.Now().UTC(), tokenHash, purpose)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.EmailToken{}, errs.ErrInvalidEmailToken
+	}
+	if err != nil {
+		return models.EmailToken{}, err
+	}
+	return token, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+)
+
+const filterColumns = "id, pattern, kind, action, enabled, created_by, created_at, updated_at"
+
+// GetContentFilters lists every content filter rule, oldest first
+func GetContentFilters() ([]models.ContentFilter, error) {
+	filters := make([]models.ContentFilter, 0)
+	err := db.GetDB().Select(&filters, "SELECT "+filterColumns+" FROM content_filters ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+// GetContentFilter retrieves a content filter rule by its ID
+func GetContentFilter(id int) (models.ContentFilter, error) {
+	var filter models.ContentFilter
+	err := db.GetDB().Get(&filter, "SELECT "+filterColumns+" FROM content_filters WHERE id = $1", id)
+	if err != nil {
+		return models.ContentFilter{}, translateError(err)
+	}
+	return filter, nil
+}
+
+// CreateContentFilter adds a content filter rule and returns its ID
+func CreateContentFilter(filter models.ContentFilter, actor models.Actor) (int, error) {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return 0, err
+	}
+
+	// The ID is taken up front so that the audit entry can point at the rule
+	var id int
+	if err := tx.Get(&id, "SELECT nextval(pg_get_serial_sequence('content_filters', 'id'))"); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	err = audited(tx, actor, models.AuditCreateFilter, models.TargetFilter, strconv.Itoa(id), "", func() error {
+		_, err := tx.Exec(`
+			INSERT INTO content_filters (id, pattern, kind, action, enabled, created_by, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			id, filter.Pattern, filter.Kind, filter.Action, filter.Enabled, actor.ID, time.Now().UTC())
+		return err
+	})
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// UpdateContentFilter replaces the pattern, kind, action and enabled flag of a rule
+func UpdateContentFilter(filter models.ContentFilter, actor models.Actor) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	err = audited(tx, actor, models.AuditUpdateFilter, models.TargetFilter, strconv.Itoa(filter.ID), "", func() error {
+		result, err := tx.Exec(`
+			UPDATE content_filters
+			SET pattern = $1, kind = $2, action = $3, enabled = $4, updated_at = $5
+			WHERE id = $6`,
+			filter.Pattern, filter.Kind, filter.Action, filter.Enabled, time.Now().UTC(), filter.ID)
+		if err != nil {
+			return err
+		}
+		return expectRow(result, errs.ErrNotFound)
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteContentFilter removes a content filter rule
+func DeleteContentFilter(id int, actor models.Actor) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	err = audited(tx, actor, models.AuditDeleteFilter, models.TargetFilter, strconv.Itoa(id), "", func() error {
+		result, err := tx.Exec("DELETE FROM content_filters WHERE id = $1", id)
+		if err != nil {
+			return err
+		}
+		return expectRow(result, errs.ErrNotFound)
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/models"
+)
+
+// fingerprintDistance counts the bits a confession's fingerprint differs in from $1
+const fingerprintDistance = "length(replace((fingerprint # $1::bigint)::bit(64)::text, '0', ''))"
+
+// FindSimilarConfessions retrieves up to limit confessions whose fingerprint
+// differs from the given one in at most maxDistance bits, closest first.
+// Only confessions created after since are considered when it is set, and
+// the confession excludeID is skipped.
+func FindSimilarConfessions(fingerprint int64, maxDistance int, since *time.Time, excludeID int, limit int) ([]models.SimilarConfession, error) {
+	similar := make([]models.SimilarConfession, 0)
+
+	query := `
+		SELECT *
+		FROM (
+			SELECT 
+				id, 
+				user_id, 
+				guest_uuid, 
+				username, 
+				title, 
+				text, 
+				anon, 
+				status, 
+				created_at, 
+				updated_at, 
+				` + fingerprintDistance + ` AS distance
+			FROM confessions
+			WHERE fingerprint IS NOT NULL
+				AND id <> $3
+				AND ($4::timestamp IS NULL OR created_at >= $4)
+		) c
+		WHERE distance <= $2
+		ORDER BY distance, created_at DESC
+		LIMIT $5`
+
+	err := db.GetDB().Select(&similar, query, fingerprint, maxDistance, excludeID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	return similar, nil
+}
+
+// GetUnfingerprintedConfessions retrieves up to limit confessions that have
+// no fingerprint yet
+func GetUnfingerprintedConfessions(limit int) ([]models.Confession, error) {
+	confessions := make([]models.Confession, 0)
+	err := db.GetDB().Select(&confessions, `
+		SELECT id, title, text
+		FROM confessions
+		WHERE fingerprint IS NULL
+		ORDER BY id
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return confessions, nil
+}
+
+// SetConfessionFingerprint stores the fingerprint of a confession
+func SetConfessionFingerprint(id int, fingerprint int64) error {
+	_, err := db.GetDB().Exec("UPDATE confessions SET fingerprint = $1 WHERE id = $2", fingerprint, id)
+	return err
+}
@@ -1,12 +1,18 @@
 package repository
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
 	"github.com/hadisjane/confessly/internal/models"
+
+	"github.com/jmoiron/sqlx"
 )
 
+const guestColumns = "uuid, " + guestBannedColumn + ", created_at, merged_into, merged_at"
+
 func CreateGuestUser(guestUser models.GuestUser) error {
 	tx, err := db.GetDB().Begin()
 	if err != nil {
@@ -14,16 +20,15 @@ func CreateGuestUser(guestUser models.GuestUser) error {
 	}
 
 	query := `
-		INSERT INTO guest_users (uuid, banned, created_at)
-		VALUES ($1, $2, $3)
+		INSERT INTO guest_users (uuid, created_at)
+		VALUES ($1, $2)
 		RETURNING uuid
 	`
 
 	err = tx.QueryRow(
 		query,
 		guestUser.UUID,
-		guestUser.Banned,
-		time.Now(),
+		time.Now().UTC(),
 	).Scan(&guestUser.UUID)
 
 	if err != nil {
@@ -37,29 +42,97 @@ func CreateGuestUser(guestUser models.GuestUser) error {
 func GetGuestUser(uuid string) (models.GuestUser, error) {
 	var guestUser models.GuestUser
 
-	err := db.GetDB().Get(&guestUser, "SELECT uuid, banned, created_at FROM guest_users WHERE uuid = $1", uuid)
+	err := db.GetDB().Get(&guestUser, "SELECT "+guestColumns+" FROM guest_users WHERE uuid = $1", uuid)
 	if err != nil {
-		return models.GuestUser{}, err
+		return models.GuestUser{}, translateError(err)
 	}
 	return guestUser, nil
 }
 
 
-func IsGuestBanned(uuid string) (bool, error) {
-	var guestUser models.GuestUser
+func GetGuestUsers(page models.PageQuery) ([]models.GuestUser, error) {
+	guestUsers := make([]models.GuestUser, 0)
+
+	cond, order, args := keyset(page, "uuid", 1)
+	limit, limitArg := limitClause(page, len(args)+1)
+	args = append(args, limitArg)
+
+	err := db.GetDB().Select(&guestUsers, "SELECT "+guestColumns+" FROM guest_users WHERE "+cond+" "+order+" "+limit, args...)
+	if err != nil {
+		return nil, err
+	}
+	return guestUsers, nil
+}
 
-	err := db.GetDB().Get(&guestUser, "SELECT banned FROM guest_users WHERE uuid = $1", uuid)
+// UpgradeLegacyGuestCookie lets a guest created before cookies were signed
+// trade its bare UUID cookie for a signed one. It reports false once the guest
+// has done so, or if there is no such guest.
+func UpgradeLegacyGuestCookie(uuid string) (bool, error) {
+	result, err := db.GetDB().Exec(`
+		UPDATE guest_users SET legacy_cookie = FALSE
+		WHERE uuid = $1 AND legacy_cookie`, uuid)
 	if err != nil {
 		return false, err
 	}
-	return guestUser.Banned, nil
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
 }
 
-func GetGuestUsers() ([]models.GuestUser, error) {
-	var guestUsers []models.GuestUser
-	err := db.GetDB().Select(&guestUsers, "SELECT uuid, banned, created_at FROM guest_users")
+// ClaimGuest hands the confessions of a guest over to a user and marks the
+// guest merged so it can't be claimed again. It returns how many confessions
+// were moved.
+func ClaimGuest(uuid string, userID int) (int64, error) {
+	tx, err := db.GetDB().Beginx()
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	return guestUsers, nil
-}
\ No newline at end of file
+
+	moved, err := claimGuest(tx, uuid, userID)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return moved, tx.Commit()
+}
+
+func claimGuest(tx *sqlx.Tx, uuid string, userID int) (int64, error) {
+	// Locking the guest makes a second claim of the same cookie wait and fail
+	var merged bool
+	err := tx.Get(&merged, "SELECT merged_at IS NOT NULL FROM guest_users WHERE uuid = $1 FOR UPDATE", uuid)
+	if err == sql.ErrNoRows {
+		return 0, errs.ErrNoGuestToClaim
+	}
+	if err != nil {
+		return 0, err
+	}
+	if merged {
+		return 0, errs.ErrGuestAlreadyClaimed
+	}
+
+	// Both owner columns change in one statement so chk_user_or_guest holds.
+	// The username and anon flag stay as they were: claiming must not reveal
+	// who wrote what was posted under the guest name.
+	result, err := tx.Exec(`
+		UPDATE confessions SET user_id = $1, guest_uuid = NULL
+		WHERE guest_uuid = $2`, userID, uuid)
+	if err != nil {
+		return 0, err
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE guest_users SET merged_into = $1, merged_at = $2
+		WHERE uuid = $3`, userID, time.Now().UTC(), uuid)
+	if err != nil {
+		return 0, err
+	}
+
+	return moved, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+)
+
+// GetLoginBlocks returns the records of the username and the IP that refuse
+// logins at now
+func GetLoginBlocks(username string, ip string, now time.Time) ([]models.LoginFailure, error) {
+	blocks := make([]models.LoginFailure, 0)
+	err := db.GetDB().Select(&blocks, `
+		SELECT * FROM login_failures
+		WHERE ((scope = $1 AND key = $2) OR (scope = $3 AND key = $4)) AND blocked_until > $5`,
+		models.LockoutAccount, username, models.LockoutIP, ip, now)
+	if err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// RecordLoginFailure loads the record of scope and key, lets record update
+// it and stores it again. The row is locked meanwhile so concurrent failures
+// are all counted. A missing record is passed as nil.
+func RecordLoginFailure(scope string, key string, record func(failure *models.LoginFailure) models.LoginFailure) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	var failure models.LoginFailure
+	var current *models.LoginFailure
+	err = tx.Get(&failure, "SELECT * FROM login_failures WHERE scope = $1 AND key = $2 FOR UPDATE", scope, key)
+	switch {
+	case err == nil:
+		current = &failure
+	case err != sql.ErrNoRows:
+		tx.Rollback()
+		return err
+	}
+
+	updated := record(current)
+
+	// Two concurrent first failures may both find no record; the second one
+	// then just overwrites the first, which costs at most one extra attempt
+	_, err = tx.Exec(`
+		INSERT INTO login_failures (scope, key, user_id, failures, last_failure_at, blocked_until, locked_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			failures = EXCLUDED.failures,
+			last_failure_at = EXCLUDED.last_failure_at,
+			blocked_until = EXCLUDED.blocked_until,
+			locked_at = EXCLUDED.locked_at,
+			created_at = EXCLUDED.created_at`,
+		scope, key, updated.UserID, updated.Failures, updated.LastFailureAt, updated.BlockedUntil, updated.LockedAt, updated.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ClearLoginFailures forgets the failures of scope and key
+func ClearLoginFailures(scope string, key string) error {
+	_, err := db.GetDB().Exec("DELETE FROM login_failures WHERE scope = $1 AND key = $2", scope, key)
+	return err
+}
+
+// PruneLoginFailures drops records whose last failure was before before and
+// that no longer block anything
+func PruneLoginFailures(before time.Time, now time.Time) error {
+	_, err := db.GetDB().Exec("DELETE FROM login_failures WHERE last_failure_at < $1 AND blocked_until <= $2", before, now)
+	return err
+}
+
+// GetLoginLockouts returns one page of the records that refuse logins at
+// now, optionally of one scope
+func GetLoginLockouts(scope string, now time.Time, page models.PageQuery) ([]models.LoginFailure, error) {
+	lockouts := make([]models.LoginFailure, 0)
+
+	where, args := "blocked_until > $1", []interface{}{now}
+	if scope != "" {
+		args = append(args, scope)
+		where += " AND scope = $2"
+	}
+	cond, order, pageArgs := keyset(page, "id", len(args)+1)
+	args = append(args, pageArgs...)
+	limit, limitArg := limitClause(page, len(args)+1)
+	args = append(args, limitArg)
+
+	err := db.GetDB().Select(&lockouts, "SELECT * FROM login_failures WHERE "+where+" AND "+cond+" "+order+" "+limit, args...)
+	if err != nil {
+		return nil, err
+	}
+	return lockouts, nil
+}
+
+// ClearLoginLockout deletes a failed login record, which lifts its lockout
+func ClearLoginLockout(id int, actor models.Actor, reason string) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	err = audited(tx, actor, models.AuditClearLockout, models.TargetLockout, strconv.Itoa(id), reason, func() error {
+		result, err := tx.Exec("DELETE FROM login_failures WHERE id = $1", id)
+		if err != nil {
+			return err
+		}
+		return expectRow(result, errs.ErrNotFound)
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
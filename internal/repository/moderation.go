@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+)
+
+// GetPendingConfessions retrieves one page of the pre-moderation queue
+func GetPendingConfessions(page models.PageQuery) ([]models.Confession, error) {
+	confessions := make([]models.Confession, 0)
+
+	cond, order, args := keyset(page, "id", 1)
+	limit, limitArg := limitClause(page, len(args)+1)
+	args = append(args, limitArg)
+
+	query := `
+		SELECT 
+			id, 
+			user_id, 
+			guest_uuid, 
+			username, 
+			title, 
+			text, 
+			anon, 
+			status, 
+			moderation_reason, 
+			created_at, 
+			updated_at
+		FROM confessions
+		WHERE status = 'pending' AND ` + cond + `
+		` + order + `
+		` + limit
+
+	err := db.GetDB().Select(&confessions, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return confessions, nil
+}
+
+// GetAutoHiddenConfessions retrieves one page of the confessions hidden by
+// reports that wait for review
+func GetAutoHiddenConfessions(page models.PageQuery) ([]models.Confession, error) {
+	confessions := make([]models.Confession, 0)
+
+	cond, order, args := keyset(page, "id", 1)
+	limit, limitArg := limitClause(page, len(args)+1)
+	args = append(args, limitArg)
+
+	query := `
+		SELECT 
+			id, 
+			user_id, 
+			guest_uuid, 
+			username, 
+			title, 
+			text, 
+			anon, 
+			status, 
+			auto_hidden_at, 
+			created_at, 
+			updated_at
+		FROM confessions
+		WHERE status = 'hidden' AND auto_hidden_at IS NOT NULL AND ` + cond + `
+		` + order + `
+		` + limit
+
+	err := db.GetDB().Select(&confessions, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return confessions, nil
+}
+
+// ModerateConfession moves a confession in one of the from statuses to the
+// status to on behalf of an admin. The reason is kept on the confession for
+// its author when it is rejected.
+func ModerateConfession(id int, from []string, to string, auditAction string, actor models.Actor, reason string) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	var status string
+	err = tx.Get(&status, "SELECT status FROM confessions WHERE id = $1 FOR UPDATE", id)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errs.ErrConfessionNotFound
+		}
+		return err
+	}
+
+	if !slices.Contains(from, status) {
+		tx.Rollback()
+		return errs.ErrInvalidModerationTransition
+	}
+
+	var moderationReason *string
+	if to == models.ConfessionRejected {
+		moderationReason = &reason
+	}
+
+	err = audited(tx, actor, auditAction, models.TargetConfession, strconv.Itoa(id), reason, func() error {
+		_, err := tx.Exec(`
+			UPDATE confessions
+			SET status = $1, moderation_reason = $2, moderated_by = $3, moderated_at = $4, auto_hidden_at = NULL
+			WHERE id = $5`, to, moderationReason, actor.ID, time.Now().UTC(), id)
+		return err
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
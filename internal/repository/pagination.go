@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/hadisjane/confessly/internal/models"
+)
+
+// keyset builds the condition and ORDER BY clause that select the rows after
+// page.After in (created_at, idColumn) order. Placeholders are numbered from
+// firstArg; the returned args must be appended to the query arguments in order.
+func keyset(page models.PageQuery, idColumn string, firstArg int) (string, string, []interface{}) {
+	op, dir := "<", "DESC"
+	if page.Sort == models.SortOld {
+		op, dir = ">", "ASC"
+	}
+
+	order := fmt.Sprintf("ORDER BY created_at %s, %s %s", dir, idColumn, dir)
+	if page.After == nil {
+		return "TRUE", order, nil
+	}
+
+	cond := fmt.Sprintf("(created_at, %s) %s ($%d, $%d)", idColumn, op, firstArg, firstArg+1)
+	return cond, order, []interface{}{page.After.CreatedAt, page.After.ID}
+}
+
+// rankKeyset is keyset for search results ordered by relevance,
+// with the id as a tie-breaker
+func rankKeyset(page models.PageQuery, firstArg int) (string, string, []interface{}) {
+	order := "ORDER BY rank DESC, id DESC"
+	if page.After == nil {
+		return "TRUE", order, nil
+	}
+
+	cond := fmt.Sprintf("(rank, id) < ($%d, $%d)", firstArg, firstArg+1)
+	return cond, order, []interface{}{page.After.Rank, page.After.ID}
+}
+
+// limitClause fetches one extra row so the service can tell whether
+// there is a next page
+func limitClause(page models.PageQuery, arg int) (string, interface{}) {
+	return fmt.Sprintf("LIMIT $%d", arg), page.Limit + 1
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/models"
+)
+
+// TakeRateLimitToken loads the bucket stored under key, lets take update it
+// and stores it again. The row is locked meanwhile so replicas take tokens
+// from the same bucket one at a time. A missing bucket is passed as nil.
+func TakeRateLimitToken(key string, take func(bucket *models.RateLimitBucket) models.RateLimitBucket) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	var bucket models.RateLimitBucket
+	var current *models.RateLimitBucket
+	err = tx.Get(&bucket, "SELECT tokens, updated_at FROM rate_limits WHERE key = $1 FOR UPDATE", key)
+	switch {
+	case err == nil:
+		current = &bucket
+	case err != sql.ErrNoRows:
+		tx.Rollback()
+		return err
+	}
+
+	updated := take(current)
+
+	// Two replicas may both find no bucket; the second one then just
+	// overwrites the first, which costs at most one extra request
+	_, err = tx.Exec(`
+		INSERT INTO rate_limits (key, tokens, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET tokens = EXCLUDED.tokens, updated_at = EXCLUDED.updated_at`,
+		key, updated.Tokens, updated.UpdatedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PruneRateLimits drops buckets that have not been used since before. Those
+// have filled up again, so they are the same as no bucket at all.
+func PruneRateLimits(before time.Time) error {
+	_, err := db.GetDB().Exec("DELETE FROM rate_limits WHERE updated_at < $1", before)
+	return err
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// AddReaction stores a reaction. Adding the same reaction twice is a no-op.
+func AddReaction(reaction models.Reaction) error {
+	exists, err := confessionExists(reaction.ConfessionID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errs.ErrConfessionNotFound
+	}
+
+	conflict := "(confession_id, kind, user_id) WHERE user_id IS NOT NULL"
+	if reaction.UserID == nil {
+		conflict = "(confession_id, kind, guest_uuid) WHERE guest_uuid IS NOT NULL"
+	}
+
+	_, err = db.GetDB().Exec(`
+		INSERT INTO reactions (confession_id, kind, user_id, guest_uuid, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT `+conflict+` DO NOTHING`,
+		reaction.ConfessionID, reaction.Kind, reaction.UserID, reaction.GuestUUID, time.Now().UTC())
+	return err
+}
+
+// RemoveReaction deletes the identity's reaction of the given kind, if any
+func RemoveReaction(reaction models.Reaction) error {
+	_, err := db.GetDB().Exec(`
+		DELETE FROM reactions
+		WHERE confession_id = $1 AND kind = $2
+		  AND (user_id = $3 OR guest_uuid = $4)`,
+		reaction.ConfessionID, reaction.Kind, reaction.UserID, reaction.GuestUUID)
+	return err
+}
+
+type reactionCount struct {
+	ConfessionID int    `db:"confession_id"`
+	Kind         string `db:"kind"`
+	Count        int    `db:"count"`
+}
+
+// GetReactionCounts returns reaction counts per kind for each of the confessions
+func GetReactionCounts(confessionIDs []int) (map[int]map[string]int, error) {
+	counts := make(map[int]map[string]int, len(confessionIDs))
+	if len(confessionIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []reactionCount
+	err := db.GetDB().Select(&rows, `
+		SELECT confession_id, kind, COUNT(*) AS count
+		FROM reactions
+		WHERE confession_id = ANY($1)
+		GROUP BY confession_id, kind`, pq.Array(confessionIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if counts[row.ConfessionID] == nil {
+			counts[row.ConfessionID] = make(map[string]int)
+		}
+		counts[row.ConfessionID][row.Kind] = row.Count
+	}
+	return counts, nil
+}
+
+// GetReactors lists who reacted to a confession, newest first
+func GetReactors(confessionID int) ([]models.Reactor, error) {
+	reactors := make([]models.Reactor, 0)
+	err := db.GetDB().Select(&reactors, `
+		SELECT r.kind, r.user_id, r.guest_uuid, COALESCE(u.username, 'Guest') AS username, r.created_at
+		FROM reactions r
+		LEFT JOIN users u ON u.id = r.user_id
+		WHERE r.confession_id = $1
+		ORDER BY r.created_at DESC, r.id DESC`, confessionID)
+	if err != nil {
+		return nil, err
+	}
+	return reactors, nil
+}
+
+// GetMyReactions returns the kinds the identity has reacted with
+func GetMyReactions(confessionID int, userID *int, guestUUID *string) ([]string, error) {
+	kinds := make([]string, 0)
+	err := db.GetDB().Select(&kinds, `
+		SELECT kind FROM reactions
+		WHERE confession_id = $1 AND (user_id = $2 OR guest_uuid = $3)
+		ORDER BY kind`, confessionID, userID, guestUUID)
+	if err != nil {
+		return nil, err
+	}
+	return kinds, nil
+}
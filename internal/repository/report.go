@@ -1,9 +1,15 @@
 package repository
 
 import (
+	"database/sql"
+	"strconv"
+	"time"
+
 	"github.com/hadisjane/confessly/internal/db"
 	"github.com/hadisjane/confessly/internal/errs"
 	"github.com/hadisjane/confessly/internal/models"
+
+	"github.com/jmoiron/sqlx"
 )
 
 // Check if confession exists
@@ -16,9 +22,12 @@ func confessionExists(confessionID int) (bool, error) {
 	return exists, nil
 }
 
-func CreateReport(report models.Report) error {
+// CreateReport files a report. A report on a confession itself is then
+// weighed together with its other open reports, and if shouldHide says so the
+// confession is hidden until an admin reviews it. shouldHide may be nil.
+func CreateReport(report models.Report, shouldHide func([]models.ReporterRecord) bool) error {
 	// Check if confession exists
-	exists, err := confessionExists(report.ConfessionID)
+	exists, err := confessionExists(*report.ConfessionID)
 	if err != nil {
 		return err
 	}
@@ -26,14 +35,14 @@ func CreateReport(report models.Report) error {
 		return errs.ErrConfessionNotFound
 	}
 
-	tx, err := db.GetDB().Begin()
+	tx, err := db.GetDB().Beginx()
 	if err != nil {
 		return err
 	}
 
-	// Check if user has already reported this confession
+	// Check if user has already reported this confession or comment
 	var reportExists bool
-	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM reports WHERE user_id = $1 AND confession_id = $2)", report.UserID, report.ConfessionID).Scan(&reportExists)
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM reports WHERE user_id = $1 AND confession_id = $2 AND comment_id IS NOT DISTINCT FROM $3)", report.UserID, report.ConfessionID, report.CommentID).Scan(&reportExists)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -44,12 +53,82 @@ func CreateReport(report models.Report) error {
 		return errs.ErrReportExists
 	}
 
-	_, err = tx.Exec("INSERT INTO reports (user_id, confession_id, reason) VALUES ($1, $2, $3)", 
-		report.UserID, report.ConfessionID, report.Reason)
+	_, err = tx.Exec("INSERT INTO reports (user_id, confession_id, comment_id, reason, created_at) VALUES ($1, $2, $3, $4, $5)", 
+		report.UserID, report.ConfessionID, report.CommentID, report.Reason, time.Now().UTC())
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
+	if shouldHide != nil && report.CommentID == nil {
+		if err := autoHideConfession(tx, *report.ConfessionID, shouldHide); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
 	return tx.Commit()
-}
\ No newline at end of file
+}
+
+// autoHideConfession hides a published confession when shouldHide decides its
+// open reports are enough
+func autoHideConfession(tx *sqlx.Tx, confessionID int, shouldHide func([]models.ReporterRecord) bool) error {
+	var status string
+	err := tx.Get(&status, "SELECT status FROM confessions WHERE id = $1 FOR UPDATE", confessionID)
+	if err != nil {
+		return translateError(err)
+	}
+	if status != models.ConfessionPublished {
+		return nil
+	}
+
+	reporters := make([]models.ReporterRecord, 0)
+	err = tx.Select(&reporters, `
+		SELECT
+			r.user_id,
+			r.created_at AS reported_at,
+			u.created_at AS account_created_at,
+			(SELECT COUNT(*) FROM reports p WHERE p.user_id = r.user_id AND p.status = 'resolved') AS resolved,
+			(SELECT COUNT(*) FROM reports p WHERE p.user_id = r.user_id AND p.status = 'dismissed') AS dismissed
+		FROM reports r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.confession_id = $1 AND r.comment_id IS NULL AND r.status IN ('pending', 'in_review')`,
+		confessionID)
+	if err != nil {
+		return err
+	}
+
+	if !shouldHide(reporters) {
+		return nil
+	}
+
+	return audited(tx, models.SystemActor, models.AuditAutoHide, models.TargetConfession, strconv.Itoa(confessionID), "reported by too many people", func() error {
+		_, err := tx.Exec("UPDATE confessions SET status = $1, auto_hidden_at = $2 WHERE id = $3",
+			models.ConfessionHidden, time.Now().UTC(), confessionID)
+		return err
+	})
+}
+
+// restoreAutoHidden publishes an automatically hidden confession again once
+// every report on it has been dismissed
+func restoreAutoHidden(tx *sqlx.Tx, confessionID int, actor models.Actor, reason string) error {
+	var restorable bool
+	err := tx.Get(&restorable, `
+		SELECT status = 'hidden' AND auto_hidden_at IS NOT NULL AND NOT EXISTS (
+			SELECT 1 FROM reports
+			WHERE confession_id = $1 AND comment_id IS NULL AND status <> 'dismissed'
+		)
+		FROM confessions WHERE id = $1 FOR UPDATE`, confessionID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil || !restorable {
+		return err
+	}
+
+	return audited(tx, actor, models.AuditAutoRestore, models.TargetConfession, strconv.Itoa(confessionID), reason, func() error {
+		_, err := tx.Exec("UPDATE confessions SET status = $1, auto_hidden_at = NULL WHERE id = $2",
+			models.ConfessionPublished, confessionID)
+		return err
+	})
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+)
+
+// SetUserRole gives a user a new role. Only owners may touch admins and
+// owners, everyone else can only move users between roles below their own.
+// The last owner can't be demoted.
+func SetUserRole(userID int, role string, actor models.Actor, reason string) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	var current string
+	if err := tx.Get(&current, "SELECT role FROM users WHERE id = $1 FOR UPDATE", userID); err != nil {
+		tx.Rollback()
+		return translateError(err)
+	}
+
+	if actor.Role != models.RoleOwner && (!models.Outranks(actor.Role, current) || !models.Outranks(actor.Role, role)) {
+		tx.Rollback()
+		return errs.ErrOutranked
+	}
+
+	if current == models.RoleOwner && role != models.RoleOwner {
+		// Locking every owner keeps two owners from demoting each other at once
+		var owners []int
+		if err := tx.Select(&owners, "SELECT id FROM users WHERE role = $1 FOR UPDATE", models.RoleOwner); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if len(owners) <= 1 {
+			tx.Rollback()
+			return errs.ErrLastOwner
+		}
+	}
+
+	err = audited(tx, actor, models.AuditSetRole, models.TargetUser, strconv.Itoa(userID), reason, func() error {
+		// Access tokens carry the role, so the ones already issued stop working
+		// and the next refresh picks up the new role
+		_, err := tx.Exec("UPDATE users SET role = $1, tokens_valid_after = $2 WHERE id = $3",
+			role, tokensValidAfter(time.Now()), userID)
+		return err
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func CreateRefreshToken(token models.RefreshToken) error {
+	_, err := db.GetDB().Exec(`
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt,
+		time.Now().UTC())
+	return err
+}
+
+// RotateRefreshToken exchanges the refresh token with oldHash for a new one in
+// the same family and returns its owner. Presenting a token that was already
+// rotated or revoked is treated as theft: the whole family is revoked and
+// ErrRefreshTokenReused is returned. allow is asked before anything changes
+// and an error from it leaves the token as it was.
+func RotateRefreshToken(oldHash string, newHash string, expiresAt time.Time, allow func(user models.User) error) (models.User, error) {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return models.User{}, err
+	}
+
+	var old models.RefreshToken
+	err = tx.Get(&old, `
+		SELECT id, user_id, family_id, token_hash, expires_at, used_at, revoked_at, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE`, oldHash)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return models.User{}, errs.ErrInvalidRefreshToken
+		}
+		return models.User{}, err
+	}
+
+	now := time.Now().UTC()
+
+	if old.UsedAt != nil || old.RevokedAt != nil {
+		_, err = tx.Exec(`
+			UPDATE refresh_tokens SET revoked_at = $1
+			WHERE family_id = $2 AND revoked_at IS NULL`, now, old.FamilyID)
+		if err != nil {
+			tx.Rollback()
+			return models.User{}, fmt.Errorf("failed to revoke token family: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return models.User{}, err
+		}
+		return models.User{}, errs.ErrRefreshTokenReused
+	}
+
+	if now.After(old.ExpiresAt) {
+		tx.Rollback()
+		return models.User{}, errs.ErrInvalidRefreshToken
+	}
+
+	var user models.User
+	err = tx.Get(&user, "SELECT id, username, role, "+userBannedColumn+", created_at FROM users WHERE id = $1", old.UserID)
+	if err != nil {
+		tx.Rollback()
+		return models.User{}, translateError(err)
+	}
+
+	if err := allow(user); err != nil {
+		tx.Rollback()
+		return models.User{}, err
+	}
+
+	if _, err = tx.Exec("UPDATE refresh_tokens SET used_at = $1 WHERE id = $2", now, old.ID); err != nil {
+		tx.Rollback()
+		return models.User{}, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		old.UserID, old.FamilyID, newHash, expiresAt, now)
+	if err != nil {
+		tx.Rollback()
+		return models.User{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+// RevokeRefreshTokenFamily revokes every token issued from the same login
+// as the user's token with the given hash
+func RevokeRefreshTokenFamily(userID int, tokenHash string) error {
+	_, err := db.GetDB().Exec(`
+		UPDATE refresh_tokens SET revoked_at = $1
+		WHERE revoked_at IS NULL AND family_id = (
+			SELECT family_id FROM refresh_tokens WHERE token_hash = $2 AND user_id = $3
+		)`, time.Now().UTC(), tokenHash, userID)
+	return err
+}
+
+// RevokeAccessToken puts a jti on the revocation list until the token expires.
+// Entries for tokens that have expired anyway are cleaned up on the way.
+func RevokeAccessToken(jti string, userID int, expiresAt time.Time) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if _, err := tx.Exec("DELETE FROM revoked_tokens WHERE expires_at < $1", now); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (jti) DO NOTHING`, jti, userID, expiresAt.UTC(), now)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeAllUserTokens revokes every refresh token of the user and
+// invalidates all access tokens issued before now
+func RevokeAllUserTokens(userID int) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err := revokeAllUserTokens(tx, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func revokeAllUserTokens(tx *sqlx.Tx, userID int) error {
+	now := time.Now().UTC()
+	_, err := tx.Exec(`
+		UPDATE refresh_tokens SET revoked_at = $1
+		WHERE user_id = $2 AND revoked_at IS NULL`, now, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE users SET tokens_valid_after = $1 WHERE id = $2", tokensValidAfter(now), userID)
+	return err
+}
+
+// tokensValidAfter is the logout-all cutoff for now. Access tokens carry their
+// issue time in microseconds, the precision of a TIMESTAMP column, so every
+// token issued before it is cut off while the next login isn't.
+func tokensValidAfter(now time.Time) time.Time {
+	return now.UTC().Truncate(time.Microsecond)
+}
+
+// IsAccessTokenRevoked checks the jti against the revocation list and the
+// issue time against the user's logout-all cutoff. Tokens issued before
+// microsecond issue times were added only have whole seconds, so one issued
+// in the same second as the cutoff counts as revoked.
+func IsAccessTokenRevoked(jti string, userID int, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	err := db.GetDB().Get(&revoked, `
+		SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)
+		    OR EXISTS(SELECT 1 FROM users WHERE id = $2 AND tokens_valid_after > $3)`,
+		jti, userID, issuedAt.UTC())
+	if err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
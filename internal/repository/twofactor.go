@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetTwoFactor returns the TOTP state of a user
+func GetTwoFactor(userID int) (models.TwoFactor, error) {
+	var tf models.TwoFactor
+	err := db.GetDB().Get(&tf, "SELECT id, totp_secret, totp_enabled_at, totp_last_step FROM users WHERE id = $1", userID)
+	if err != nil {
+		return models.TwoFactor{}, translateError(err)
+	}
+	return tf, nil
+}
+
+// CountRecoveryCodes counts the recovery codes the user hasn't used yet
+func CountRecoveryCodes(userID int) (int, error) {
+	var n int
+	err := db.GetDB().Get(&n, "SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL", userID)
+	return n, err
+}
+
+// SetTOTPSecret stores a secret that waits for confirmation. A secret that
+// was never confirmed is replaced, an enabled one is left alone.
+func SetTOTPSecret(userID int, secret string) error {
+	result, err := db.GetDB().Exec(`
+		UPDATE users SET totp_secret = $1, totp_last_step = 0
+		WHERE id = $2 AND totp_enabled_at IS NULL`, secret, userID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, errs.ErrTwoFactorAlreadyEnabled)
+}
+
+// EnableTwoFactor turns 2FA on once the pending secret was confirmed with the
+// code of step, and stores the first recovery codes
+func EnableTwoFactor(userID int, step int64, codeHashes []string) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err := useTOTPStep(tx, userID, step); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE users SET totp_enabled_at = $1
+		WHERE id = $2 AND totp_enabled_at IS NULL AND totp_secret IS NOT NULL`,
+		time.Now().UTC(), userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := expectRow(result, errs.ErrTwoFactorAlreadyEnabled); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DisableTwoFactor forgets the secret and the recovery codes of a user
+func DisableTwoFactor(userID int) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0
+		WHERE id = $1`, userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes swaps every recovery code of a user for new ones
+func ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sqlx.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		_, err := tx.Exec("INSERT INTO recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, $3)",
+			userID, hash, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseTOTPStep accepts the code of step unless a code of that step or a later
+// one was accepted already
+func UseTOTPStep(userID int, step int64) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err := useTOTPStep(tx, userID, step); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func useTOTPStep(tx *sqlx.Tx, userID int, step int64) error {
+	result, err := tx.Exec("UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1", step, userID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, errs.ErrInvalidTwoFactorCode)
+}
+
+// UseRecoveryCode uses up an unused recovery code of the user
+func UseRecoveryCode(userID int, codeHash string) error {
+	result, err := db.GetDB().Exec(`
+		UPDATE recovery_codes SET used_at = $1
+		WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL`,
+		time.Now().UTC(), userID, codeHash)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, errs.ErrInvalidTwoFactorCode)
+}
+
+// CreateLoginChallenge stores the second step of a login. Challenges that
+// have expired anyway are cleaned up on the way.
+func CreateLoginChallenge(userID int, tokenHash string, expiresAt time.Time) error {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if _, err := tx.Exec("DELETE FROM login_challenges WHERE expires_at < $1", now); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO login_challenges (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)`, userID, tokenHash, expiresAt, now)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReserveLoginChallengeAttempt takes one attempt of a challenge that can
+// still be answered and returns the challenge. The attempt is counted before
+// the code is checked, so concurrent guesses can't get past maxAttempts.
+func ReserveLoginChallengeAttempt(tokenHash string, maxAttempts int) (models.LoginChallenge, error) {
+	var challenge models.LoginChallenge
+	err := db.GetDB().Get(&challenge, `
+		UPDATE login_challenges SET attempts = attempts + 1
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2 AND attempts < $3
+		RETURNING id, user_id, token_hash, attempts, expires_at, used_at, created_at`,
+		tokenHash, time.Now().UTC(), maxAttempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.LoginChallenge{}, errs.ErrInvalidLoginChallenge
+	}
+	if err != nil {
+		return models.LoginChallenge{}, err
+	}
+	return challenge, nil
+}
+
+// CompleteLoginChallenge uses up a challenge. It fails if a concurrent
+// request got there first.
+func CompleteLoginChallenge(id int) error {
+	result, err := db.GetDB().Exec("UPDATE login_challenges SET used_at = $1 WHERE id = $2 AND used_at IS NULL",
+		time.Now().UTC(), id)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, errs.ErrInvalidLoginChallenge)
+}
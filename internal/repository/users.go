@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/hadisjane/confessly/internal/db"
 	"github.com/hadisjane/confessly/internal/errs"
 	"github.com/hadisjane/confessly/internal/models"
@@ -24,9 +26,11 @@ func GetUserByUsernameAndPassword(username string, password string) (models.User
 func GetUserByUsername(username string) (user models.User, err error) {
 	err = db.GetDB().Get(&user, `SELECT id, 
 					   username,
+					   email,
 					   role,
 					   password,
-					   created_at
+					   created_at,
+					   password_change_required
 				FROM users WHERE username = $1`, username)
 	if err != nil {
 		return models.User{}, translateError(err)
@@ -35,44 +39,94 @@ func GetUserByUsername(username string) (user models.User, err error) {
 	return user, nil
 }
 
-func CreateUser(user models.UserRegister) error {
-	_, err := db.GetDB().Exec(`
-		INSERT INTO users (username, email, password)
-		VALUES ($1, $2, $3)`,
+// CreateUser stores a new user and returns its ID. When claimGuestUUID is
+// set, the guest identity is claimed for the new user in the same transaction
+// and the number of confessions moved is returned.
+func CreateUser(user models.UserRegister, claimGuestUUID *string) (int, int64, error) {
+	tx, err := db.GetDB().Beginx()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var userID int
+	err = tx.QueryRow(`
+		INSERT INTO users (username, email, password, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
 		user.Username,
 		user.Email,
-		user.Password)
-	return err
-}
+		user.Password,
+		time.Now().UTC()).Scan(&userID)
+	if err != nil {
+		tx.Rollback()
+		return 0, 0, err
+	}
+
+	var claimed int64
+	if claimGuestUUID != nil {
+		claimed, err = claimGuest(tx, *claimGuestUUID, userID)
+		if err != nil {
+			tx.Rollback()
+			return 0, 0, err
+		}
+	}
 
-// UpdateUserBannedStatus updates the banned status of a user
-func UpdateUserBannedStatus(id int, banned bool) error {
-	result, err := db.GetDB().Exec(`
-		UPDATE users 
-		SET banned = $1 
-		WHERE id = $2`, banned, id)
+	return userID, claimed, tx.Commit()
+}
 
+// RequirePasswordChange makes the user change their password before the
+// next login and ends their sessions. It reports whether the flag was newly set.
+func RequirePasswordChange(userID int) (bool, error) {
+	tx, err := db.GetDB().Beginx()
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	result, err := tx.Exec("UPDATE users SET password_change_required = TRUE WHERE id = $1 AND NOT password_change_required", userID)
 	if err != nil {
-		return err
+		tx.Rollback()
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if rows == 0 {
+		tx.Rollback()
+		return false, nil
 	}
 
-	if rowsAffected == 0 {
-		return errs.ErrNotFound
+	if err := revokeAllUserTokens(tx, userID); err != nil {
+		tx.Rollback()
+		return false, err
 	}
 
-	return nil
+	return true, tx.Commit()
 }
 
-func IsUserBanned(id int) (bool, error) {
-	var banned bool
-	err := db.GetDB().Get(&banned, "SELECT banned FROM users WHERE id = $1", id)
+// ChangePassword sets a new password hash, lifts a required password change
+// and signs the user out everywhere
+func ChangePassword(userID int, passwordHash string) error {
+	tx, err := db.GetDB().Beginx()
 	if err != nil {
-		return false, err
+		return err
+	}
+
+	result, err := tx.Exec("UPDATE users SET password = $1, password_change_required = FALSE WHERE id = $2", passwordHash, userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := expectRow(result, errs.ErrNotFound); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := revokeAllUserTokens(tx, userID); err != nil {
+		tx.Rollback()
+		return err
 	}
-	return banned, nil
-}
\ No newline at end of file
+
+	return tx.Commit()
+}
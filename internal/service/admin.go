@@ -1,16 +1,57 @@
 package service
 
 import (
+	"github.com/hadisjane/confessly/internal/errs"
 	"github.com/hadisjane/confessly/internal/models"
 	"github.com/hadisjane/confessly/internal/repository"
+	"slices"
+	"strconv"
 )
 
-func GetReports() []models.Report {
-	return repository.GetReports()
-}	
+func GetReports(params models.PageParams) (models.ReportPage, error) {
+	page, err := newPageQuery(params)
+	if err != nil {
+		return models.ReportPage{}, err
+	}
+
+	reports, err := repository.GetReports(page)
+	if err != nil {
+		return models.ReportPage{}, err
+	}
+
+	if !hasNextPage(len(reports), page) {
+		return models.ReportPage{Reports: reports}, nil
+	}
+
+	reports = reports[:page.Limit]
+	last := reports[len(reports)-1]
+	return models.ReportPage{
+		Reports:    reports,
+		NextCursor: encodeCursor(last.CreatedAt, strconv.Itoa(last.ID), page.Sort),
+	}, nil
+}
+
+func GetUsers(params models.PageParams) (models.UserPage, error) {
+	page, err := newPageQuery(params)
+	if err != nil {
+		return models.UserPage{}, err
+	}
+
+	users, err := repository.GetUsers(page)
+	if err != nil {
+		return models.UserPage{}, err
+	}
+
+	if !hasNextPage(len(users), page) {
+		return models.UserPage{Users: users}, nil
+	}
 
-func GetUsers() []models.User {
-	return repository.GetUsers()
+	users = users[:page.Limit]
+	last := users[len(users)-1]
+	return models.UserPage{
+		Users:      users,
+		NextCursor: encodeCursor(last.CreatedAt, strconv.Itoa(last.ID), page.Sort),
+	}, nil
 }
 
 func GetUserByID(id int) (models.User, error) {
@@ -18,43 +59,76 @@ func GetUserByID(id int) (models.User, error) {
 }
 
 // BanUser bans a user by ID
-func BanUser(id int) error {
+func BanUser(id int, req models.BanRequest, actor models.Actor) error {
+	ban, err := newBan(req, actor)
+	if err != nil {
+		return err
+	}
+
 	// First check if user exists
-	_, err := repository.GetUserByID(id)
+	_, err = repository.GetUserByID(id)
 	if err != nil {
 		return err
 	}
 
-	// Update user's banned status
-	return repository.UpdateUserBannedStatus(id, true)
+	ban.UserID = &id
+	return repository.CreateBan(ban, actor)
 }
 
-// UnbanUser unbans a user by ID
-func UnbanUser(id int) error {
+// UnbanUser lifts every ban in force against a user
+func UnbanUser(id int, actor models.Actor, reason string) error {
 	// First check if user exists
 	_, err := repository.GetUserByID(id)
 	if err != nil {
 		return err
 	}
 
-	// Update user's banned status
-	return repository.UpdateUserBannedStatus(id, false)
+	return repository.LiftBans(&id, nil, actor, reason)
 }
 
-func DeleteConfessionByAdmin(confessionID int) error {
-	return repository.DeleteConfessionByAdmin(confessionID)
+func DeleteConfessionByAdmin(confessionID int, actor models.Actor, reason string) error {
+	return repository.DeleteConfessionByAdmin(confessionID, actor, reason)
 }
 
-func BanGuestUser(uuid string) error {
-	return repository.BanGuestUser(uuid)
+// BanGuestUser bans a guest by UUID
+func BanGuestUser(uuid string, req models.BanRequest, actor models.Actor) error {
+	ban, err := newBan(req, actor)
+	if err != nil {
+		return err
+	}
+
+	ban.GuestUUID = &uuid
+	return repository.CreateBan(ban, actor)
 }
 
-func UnbanGuestUser(uuid string) error {
-	return repository.UnbanGuestUser(uuid)
+// UnbanGuestUser lifts every ban in force against a guest
+func UnbanGuestUser(uuid string, actor models.Actor, reason string) error {
+	return repository.LiftBans(nil, &uuid, actor, reason)
 }
 
-func UpdateReport(reportID int, updateReq models.UpdateReport) error {
-	return repository.UpdateReport(reportID, updateReq)
+// UpdateReport moves a report through its lifecycle on behalf of an admin
+func UpdateReport(reportID int, actor models.Actor, updateReq models.UpdateReport) error {
+	if err := validateReportUpdate(updateReq); err != nil {
+		return err
+	}
+
+	return repository.UpdateReport(reportID, actor, updateReq)
+}
+
+func validateReportUpdate(updateReq models.UpdateReport) error {
+	if updateReq.Status == nil || !models.IsReportStatus(*updateReq.Status) {
+		return errs.ErrInvalidReportStatus
+	}
+
+	if updateReq.Action != nil {
+		if !slices.Contains(models.ResolutionActions, *updateReq.Action) {
+			return errs.ErrInvalidResolutionAction
+		}
+		if *updateReq.Status != models.ReportResolved {
+			return errs.ErrActionRequiresResolve
+		}
+	}
+	return nil
 }
 
 func GetReport(reportID int) (models.Report, error) {
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"strconv"
+
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+)
+
+// GetModerationActions returns one page of the moderation audit log
+func GetModerationActions(filter models.AuditFilter, params models.PageParams) (models.ModerationActionPage, error) {
+	page, err := newPageQuery(params)
+	if err != nil {
+		return models.ModerationActionPage{}, err
+	}
+
+	actions, err := repository.GetModerationActions(filter, page)
+	if err != nil {
+		return models.ModerationActionPage{}, err
+	}
+
+	if !hasNextPage(len(actions), page) {
+		return models.ModerationActionPage{Actions: actions}, nil
+	}
+
+	actions = actions[:page.Limit]
+	last := actions[len(actions)-1]
+	return models.ModerationActionPage{
+		Actions:    actions,
+		NextCursor: encodeCursor(last.CreatedAt, strconv.Itoa(last.ID), page.Sort),
+	}, nil
+}
+
+// ExportModerationActions passes every audit log entry matching the filter to fn
+func ExportModerationActions(filter models.AuditFilter, fn func(models.ModerationAction) error) error {
+	return repository.ExportModerationActions(filter, fn)
+}
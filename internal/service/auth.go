@@ -0,0 +1,91 @@
+package service
+
+import (
+	"time"
+
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+	"github.com/hadisjane/confessly/utils"
+
+	"github.com/google/uuid"
+)
+
+// IssueTokens starts a new refresh token family for the user and returns
+// a fresh access/refresh token pair
+func IssueTokens(user models.User) (models.TokenPair, error) {
+	refreshToken, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	err = repository.CreateRefreshToken(models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  uuid.New().String(),
+		TokenHash: hash,
+		ExpiresAt: refreshTokenExpiry(),
+	})
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	return newTokenPair(user, refreshToken)
+}
+
+// RefreshTokens rotates the refresh token and issues a new access token
+func RefreshTokens(refreshToken string) (models.TokenPair, error) {
+	newRefreshToken, newHash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	// A banned user keeps the refresh token they came with, so it still works
+	// once the ban is lifted
+	user, err := repository.RotateRefreshToken(utils.HashToken(refreshToken), newHash, refreshTokenExpiry(), func(user models.User) error {
+		return CheckUserBan(user.ID, models.BanScopeLogin)
+	})
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	return newTokenPair(user, newRefreshToken)
+}
+
+// Logout revokes the access token it was called with and, if given,
+// the refresh token family of that session
+func Logout(claims *utils.CustomClaims, refreshToken string) error {
+	if err := repository.RevokeAccessToken(claims.Id, claims.UserID, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+	return repository.RevokeRefreshTokenFamily(claims.UserID, utils.HashToken(refreshToken))
+}
+
+// LogoutAll signs the user out of every session
+func LogoutAll(userID int) error {
+	return repository.RevokeAllUserTokens(userID)
+}
+
+func IsAccessTokenRevoked(claims *utils.CustomClaims) (bool, error) {
+	return repository.IsAccessTokenRevoked(claims.Id, claims.UserID, claims.IssuedAtTime())
+}
+
+func newTokenPair(user models.User, refreshToken string) (models.TokenPair, error) {
+	accessToken, err := utils.GenerateToken(user.ID, user.Username, user.Role)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	return models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(utils.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+func refreshTokenExpiry() time.Time {
+	return time.Now().UTC().Add(utils.RefreshTokenTTL())
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"time"
+
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+)
+
+// CheckUserBan returns a *errs.BanError if a ban in force stops the user from
+// doing what scope covers. A login ban also covers posting.
+func CheckUserBan(userID int, scope string) error {
+	ban, err := repository.GetActiveBan(&userID, nil, bannedScopes(scope))
+	if err != nil || ban == nil {
+		return err
+	}
+
+	message := "your account has been banned"
+	if ban.Scope == models.BanScopePosting {
+		message = "your account has been banned from posting"
+	}
+	return newBanError(message, ban)
+}
+
+// CheckGuestBan is CheckUserBan for guests
+func CheckGuestBan(uuid string, scope string) error {
+	ban, err := repository.GetActiveBan(nil, &uuid, bannedScopes(scope))
+	if err != nil || ban == nil {
+		return err
+	}
+
+	message := "your guest account has been banned"
+	if ban.Scope == models.BanScopePosting {
+		message = "your guest account has been banned from posting"
+	}
+	return newBanError(message, ban)
+}
+
+// GetUserBans returns the ban history of a user, newest first
+func GetUserBans(id int) ([]models.Ban, error) {
+	if _, err := repository.GetUserByID(id); err != nil {
+		return nil, err
+	}
+	return repository.GetBans(&id, nil)
+}
+
+// GetGuestBans returns the ban history of a guest, newest first
+func GetGuestBans(uuid string) ([]models.Ban, error) {
+	if _, err := repository.GetGuestUser(uuid); err != nil {
+		return nil, err
+	}
+	return repository.GetBans(nil, &uuid)
+}
+
+func newBan(req models.BanRequest, actor models.Actor) (models.Ban, error) {
+	ban := models.Ban{
+		Scope:     req.Scope,
+		Reason:    req.Reason,
+		IssuedBy:  &actor.ID,
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if ban.Scope == "" {
+		ban.Scope = models.BanScopeLogin
+	}
+	if ban.Scope != models.BanScopeLogin && ban.Scope != models.BanScopePosting {
+		return models.Ban{}, errs.ErrInvalidBanScope
+	}
+	if ban.ExpiresAt != nil && !ban.ExpiresAt.After(time.Now()) {
+		return models.Ban{}, errs.ErrBanExpiryInPast
+	}
+
+	return ban, nil
+}
+
+func bannedScopes(scope string) []string {
+	if scope == models.BanScopePosting {
+		return []string{models.BanScopeLogin, models.BanScopePosting}
+	}
+	return []string{models.BanScopeLogin}
+}
+
+func newBanError(message string, ban *models.Ban) error {
+	return &errs.BanError{
+		Message:   message,
+		Scope:     ban.Scope,
+		Reason:    ban.Reason,
+		ExpiresAt: ban.ExpiresAt,
+	}
+}
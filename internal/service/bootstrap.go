@@ -0,0 +1,211 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/configs"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+	"github.com/hadisjane/confessly/logger"
+	"github.com/hadisjane/confessly/utils"
+)
+
+// The account older versions seeded on every start
+const (
+	defaultAdminUsername = "admin"
+	defaultAdminPassword = "admin"
+)
+
+const bootstrapTokenTTL = 24 * time.Hour
+
+// CheckDefaultCredentials looks for the seeded admin/admin account. In release
+// mode it is an error while the password still works; otherwise the account
+// has to change its password before it can log in again.
+func CheckDefaultCredentials() error {
+	user, err := repository.GetUserByUsername(defaultAdminUsername)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if utils.VerifyPassword(user.Password, defaultAdminPassword) != nil {
+		return nil
+	}
+
+	if configs.AppSettings.AppParams.GinMode == "release" {
+		return fmt.Errorf("user %q still has the default password, change it with `confessly admin password --username %s` before starting in release mode",
+			defaultAdminUsername, defaultAdminUsername)
+	}
+
+	flagged, err := repository.RequirePasswordChange(user.ID)
+	if err != nil {
+		return err
+	}
+	if flagged {
+		logger.Warn.Printf("User %q still has the default password, it has to be changed at /auth/password/change before the next login", defaultAdminUsername)
+	}
+	return nil
+}
+
+// StartBootstrap prints a one-time token for creating the first owner when
+// there is none yet and the bootstrap token is enabled. A token printed
+// earlier, by this or another replica, keeps working until it expires.
+func StartBootstrap() error {
+	if !configs.AppSettings.AuthParams.BootstrapToken {
+		return nil
+	}
+
+	exists, err := repository.OwnerExists()
+	if err != nil || exists {
+		return err
+	}
+
+	token, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	created, err := repository.CreateBootstrapToken(hash, time.Now().UTC().Add(bootstrapTokenTTL))
+	if err != nil {
+		return err
+	}
+	if !created {
+		logger.Info.Printf("There is no owner yet. A bootstrap token printed earlier is still valid, find it in the log of the process that printed it, or run `confessly admin create`")
+		return nil
+	}
+
+	logger.Info.Printf("There is no owner yet. Create one within %v with POST /auth/bootstrap and the one-time token %s, or run `confessly admin create`",
+		bootstrapTokenTTL, token)
+	return nil
+}
+
+// Bootstrap creates the first owner with the token printed on start
+func Bootstrap(req models.BootstrapRequest) error {
+	user, err := newStaffUser(req.Username, req.Email, req.Password, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if _, err := repository.CompleteBootstrap(utils.HashToken(req.Token), user); err != nil {
+		return err
+	}
+
+	logger.Info.Printf("Owner %q created with the bootstrap token", user.Username)
+	return nil
+}
+
+// CreateStaffAccount creates a moderator, admin or owner. Without a role the
+// account is an owner if there is none yet, and an admin otherwise.
+func CreateStaffAccount(username string, email string, password string, role string) (models.User, error) {
+	if role == "" {
+		exists, err := repository.OwnerExists()
+		if err != nil {
+			return models.User{}, err
+		}
+		role = models.RoleOwner
+		if exists {
+			role = models.RoleAdmin
+		}
+	}
+	if !models.IsRole(role) || role == models.RoleUser {
+		return models.User{}, errs.ErrInvalidRole
+	}
+
+	user, err := newStaffUser(username, email, password, role)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user.ID, err = repository.CreateStaffUser(user)
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// SetPassword replaces the password of a user without knowing the current
+// one. It is meant for the command line.
+func SetPassword(username string, password string) error {
+	user, err := repository.GetUserByUsername(username)
+	if err != nil {
+		return err
+	}
+	if err := checkStrongPassword(password, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return repository.ChangePassword(user.ID, hashedPassword)
+}
+
+// ChangePassword replaces a password given the current one and ends every
+// session of the user. It asks for everything a login does: banned users are
+// refused and accounts with 2FA need a code, otherwise a stolen password alone
+// could lock the owner out. Staff and accounts that have to change their
+// password need a strong one. Wrong current passwords count as failed logins.
+func ChangePassword(req models.ChangePasswordRequest, ip string) error {
+	user, err := authenticate(req.Username, req.CurrentPassword, ip)
+	if err != nil {
+		return err
+	}
+
+	if err := CheckUserBan(user.ID, models.BanScopeLogin); err != nil {
+		return err
+	}
+
+	twoFactor, err := HasTwoFactor(user.ID)
+	if err != nil {
+		return err
+	}
+	if twoFactor {
+		if err := verifyLoginTwoFactorCode(user, req.TwoFactorCode, ip); err != nil {
+			return err
+		}
+	}
+
+	if req.NewPassword == req.CurrentPassword {
+		return errs.ErrSamePassword
+	}
+	if user.PasswordChangeRequired || user.Role != models.RoleUser {
+		if err := checkStrongPassword(req.NewPassword, user.Username, user.Email); err != nil {
+			return err
+		}
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	return repository.ChangePassword(user.ID, hashedPassword)
+}
+
+// newStaffUser checks the details of a staff account and hashes its password
+func newStaffUser(username string, email string, password string, role string) (models.User, error) {
+	username, email = strings.TrimSpace(username), strings.TrimSpace(email)
+	if username == "" || email == "" {
+		return models.User{}, errs.ErrEmptyUsernameOrEmail
+	}
+	if err := checkStrongPassword(password, username, email); err != nil {
+		return models.User{}, err
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return models.User{}, err
+	}
+	return models.User{Username: username, Email: email, Password: hashedPassword, Role: role}, nil
+}
+
+func checkStrongPassword(password string, username string, email string) error {
+	if reason := utils.PasswordWeakness(password, username, email); reason != "" {
+		return fmt.Errorf("%w: %s", errs.ErrWeakPassword, reason)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxBulkOperations = 100
+	// maxBulkMatches caps how many confessions one filter may select
+	maxBulkMatches = 500
+)
+
+// bulkPermissions is what the actor needs for each action on top of bulk.run
+var bulkPermissions = map[string]string{
+	models.BulkDeleteConfession: models.PermConfessionsDelete,
+	models.BulkHideConfession:   models.PermConfessionsModerate,
+	models.BulkBanUser:          models.PermUsersBan,
+	models.BulkBanGuest:         models.PermUsersBan,
+	models.BulkResolveReport:    models.PermReportsResolve,
+}
+
+// RunBulk validates every operation of a bulk request up front, so that a
+// mistake in one of them fails the request before anything is changed, and
+// then carries them out
+func RunBulk(req models.BulkRequest, actor models.Actor) (models.BulkResponse, error) {
+	if len(req.Operations) == 0 {
+		return models.BulkResponse{}, errs.ErrInvalidBulkOperation
+	}
+	if len(req.Operations) > maxBulkOperations {
+		return models.BulkResponse{}, fmt.Errorf("%w: at most %d operations", errs.ErrBulkTooLarge, maxBulkOperations)
+	}
+
+	for i := range req.Operations {
+		if err := prepareBulkOperation(&req.Operations[i], req.Reason, actor); err != nil {
+			return models.BulkResponse{}, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	results, err := repository.RunBulkOperations(req.Operations, actor, req.Atomic)
+	if err != nil {
+		return models.BulkResponse{}, err
+	}
+
+	response := models.BulkResponse{Atomic: req.Atomic, Results: results}
+	for _, result := range results {
+		if result.Status == models.BulkOK {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	return response, nil
+}
+
+// prepareBulkOperation checks an operation and fills in what it acts on
+func prepareBulkOperation(op *models.BulkOperation, reason string, actor models.Actor) error {
+	if op.Reason == "" {
+		op.Reason = reason
+	}
+
+	permission, ok := bulkPermissions[op.Action]
+	if !ok {
+		return errs.ErrInvalidBulkAction
+	}
+	if !models.HasPermission(actor.Role, permission) {
+		return fmt.Errorf("%w: %s needs the %s permission", errs.ErrForbidden, op.Action, permission)
+	}
+
+	switch op.Action {
+	case models.BulkDeleteConfession, models.BulkHideConfession:
+		ids, err := bulkConfessionIDs(*op)
+		if err != nil {
+			return err
+		}
+		op.ConfessionIDs = ids
+
+	case models.BulkBanUser, models.BulkBanGuest:
+		ban, err := newBan(models.BanRequest{Reason: op.Reason, Scope: op.Scope, ExpiresAt: op.ExpiresAt}, actor)
+		if err != nil {
+			return err
+		}
+		if op.Action == models.BulkBanUser {
+			if op.UserID == nil || *op.UserID <= 0 {
+				return fmt.Errorf("%w: ban_user needs user_id", errs.ErrInvalidBulkOperation)
+			}
+			ban.UserID = op.UserID
+		} else {
+			if op.GuestUUID == nil || !isUUID(*op.GuestUUID) {
+				return fmt.Errorf("%w: ban_guest needs a valid guest_uuid", errs.ErrInvalidBulkOperation)
+			}
+			ban.GuestUUID = op.GuestUUID
+		}
+		op.Ban = ban
+
+	case models.BulkResolveReport:
+		if op.ReportID == nil || *op.ReportID <= 0 {
+			return fmt.Errorf("%w: resolve_report needs report_id", errs.ErrInvalidBulkOperation)
+		}
+		status := op.Status
+		if status == "" {
+			status = models.ReportResolved
+		}
+		if status != models.ReportResolved && status != models.ReportDismissed {
+			return fmt.Errorf("%w: a report can only be resolved or dismissed", errs.ErrInvalidBulkOperation)
+		}
+		update := models.UpdateReport{Status: &status, Action: op.ReportAction}
+		if op.Reason != "" {
+			update.Note = &op.Reason
+		}
+		if err := validateReportUpdate(update); err != nil {
+			return err
+		}
+		op.ReportUpdate = update
+
+	default:
+		return errs.ErrInvalidBulkAction
+	}
+	return nil
+}
+
+// bulkConfessionIDs resolves the confession_id or filter of a confession action
+func bulkConfessionIDs(op models.BulkOperation) ([]int, error) {
+	if (op.ConfessionID == nil) == (op.Filter == nil) {
+		return nil, fmt.Errorf("%w: %s needs either confession_id or filter", errs.ErrInvalidBulkOperation, op.Action)
+	}
+	if op.ConfessionID != nil {
+		if *op.ConfessionID <= 0 {
+			return nil, errs.ErrInvalidId
+		}
+		return []int{*op.ConfessionID}, nil
+	}
+
+	filter := *op.Filter
+	// A filter has to name an author, otherwise it could select everything
+	if filter.UserID == nil && filter.GuestUUID == nil {
+		return nil, fmt.Errorf("%w: filter needs user_id or guest_uuid", errs.ErrInvalidBulkOperation)
+	}
+	if filter.GuestUUID != nil && !isUUID(*filter.GuestUUID) {
+		return nil, fmt.Errorf("%w: invalid filter guest_uuid", errs.ErrInvalidBulkOperation)
+	}
+	// Timestamps are stored in UTC without a zone
+	if filter.Since != nil {
+		since := filter.Since.UTC()
+		filter.Since = &since
+	}
+	if filter.Until != nil {
+		until := filter.Until.UTC()
+		filter.Until = &until
+	}
+
+	ids, err := repository.FindConfessionIDs(filter, maxBulkMatches+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > maxBulkMatches {
+		return nil, fmt.Errorf("%w: filter selects more than %d confessions", errs.ErrBulkTooLarge, maxBulkMatches)
+	}
+	return ids, nil
+}
+
+func isUUID(s string) bool {
+	_, err := uuid.Parse(s)
+	return err == nil
+}
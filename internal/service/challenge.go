@@ -0,0 +1,84 @@
+package service
+
+import (
+	"time"
+
+	"github.com/hadisjane/confessly/internal/configs"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+	"github.com/hadisjane/confessly/utils"
+)
+
+// maxChallengeSolution keeps clients from making the server hash huge inputs
+const maxChallengeSolution = 64
+
+// NewChallenge issues a proof-of-work challenge bound to a guest
+func NewChallenge(guestUUID string) (models.Challenge, error) {
+	params := configs.AppSettings.ChallengeParams
+	if !params.Enabled {
+		return models.Challenge{Required: false}, nil
+	}
+
+	difficulty, err := challengeDifficulty(guestUUID)
+	if err != nil {
+		return models.Challenge{}, err
+	}
+
+	nonce, _, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return models.Challenge{}, err
+	}
+
+	expiresAt := time.Unix(time.Now().Add(time.Duration(params.TTLSeconds)*time.Second).Unix(), 0).UTC()
+	return models.Challenge{
+		Required:   true,
+		Challenge:  utils.SignChallenge(nonce, guestUUID, difficulty, expiresAt),
+		Algorithm:  models.ChallengeAlgorithm,
+		Difficulty: difficulty,
+		ExpiresAt:  &expiresAt,
+	}, nil
+}
+
+// VerifyChallenge checks the guest's solution to a challenge. It is nil when
+// challenges are off. The challenge is only spent when CreateConfession stores
+// the confession, so a guest whose confession is turned away can fix it and
+// send it again with the same solution.
+func VerifyChallenge(guestUUID string, challenge string, solution string) (*models.SolvedChallenge, error) {
+	if !configs.AppSettings.ChallengeParams.Enabled {
+		return nil, nil
+	}
+
+	if challenge == "" || solution == "" {
+		return nil, errs.ErrChallengeRequired
+	}
+
+	token, err := utils.ParseChallenge(challenge)
+	if err != nil || token.GuestUUID != guestUUID {
+		return nil, errs.ErrChallengeInvalid
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return nil, errs.ErrChallengeExpired
+	}
+	if len(solution) > maxChallengeSolution || !utils.CheckProofOfWork(challenge, solution, token.Difficulty) {
+		return nil, errs.ErrChallengeUnsolved
+	}
+
+	return &models.SolvedChallenge{Nonce: token.Nonce, ExpiresAt: token.ExpiresAt}, nil
+}
+
+// challengeDifficulty starts at the base difficulty and adds a bit for every
+// volume_step guest confessions in the last hour, a bit for every confession
+// of this guest in the last hour and two for each of its hidden or rejected
+// confessions
+func challengeDifficulty(guestUUID string) (int, error) {
+	params := configs.AppSettings.ChallengeParams
+
+	stats, err := repository.GetGuestPostingStats(guestUUID, time.Now().UTC().Add(-time.Hour))
+	if err != nil {
+		return 0, err
+	}
+
+	difficulty := params.BaseDifficulty + stats.GuestVolume/params.VolumeStep + stats.Recent + 2*stats.Hidden
+	return min(difficulty, params.MaxDifficulty), nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hadisjane/confessly/internal/configs"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+)
+
+// CreateComment adds a comment or a reply to a confession and returns it.
+// The original confessor always comments anonymously on an anonymous
+// confession, otherwise their comments would reveal who wrote it.
+func CreateComment(comment models.Comment) (models.Comment, error) {
+	if (comment.UserID == nil) == (comment.GuestUUID == nil) {
+		return models.Comment{}, errs.ErrUnauthorized
+	}
+
+	text, err := validateCommentText(comment.Text)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	comment.Text = text
+
+	confession, err := requirePublished(comment.ConfessionID)
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	comment.Depth = 0
+	if comment.ParentID != nil {
+		parent, err := repository.GetComment(*comment.ParentID)
+		if err != nil {
+			return models.Comment{}, err
+		}
+		if parent.ConfessionID != comment.ConfessionID {
+			return models.Comment{}, errs.ErrCommentParentMismatch
+		}
+		if parent.Status != models.CommentVisible {
+			return models.Comment{}, errs.ErrCommentNotFound
+		}
+		if parent.Depth >= configs.AppSettings.CommentParams.MaxDepth {
+			return models.Comment{}, errs.ErrCommentTooDeep
+		}
+		comment.Depth = parent.Depth + 1
+	}
+
+	if confession.Anon && isConfessionAuthor(confession, comment.UserID, comment.GuestUUID) {
+		comment.Anon = true
+	}
+
+	identity := commentIdentity(comment.UserID, comment.GuestUUID)
+	id, err := repository.CreateComment(comment, identity, aliasAnimal())
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	created, err := repository.GetComment(id)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	created.IsOP = isConfessionAuthor(confession, created.UserID, created.GuestUUID)
+
+	return created, nil
+}
+
+// GetCommentThread returns the comments on a confession as a tree of
+// top-level comments with their replies, oldest first. Comments on a
+// confession that isn't published are only shown to its author and moderators.
+func GetCommentThread(confessionID int, userID *int, guestUUID *string, moderator bool) ([]*models.Comment, error) {
+	confession, err := repository.GetConfession(confessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !CanViewConfession(confession, userID, guestUUID, moderator) {
+		return nil, errs.ErrConfessionNotFound
+	}
+
+	comments, err := repository.GetComments(confessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*models.Comment, len(comments))
+	for _, comment := range comments {
+		comment.IsOP = isConfessionAuthor(confession, comment.UserID, comment.GuestUUID)
+		byID[comment.ID] = comment
+	}
+
+	thread := make([]*models.Comment, 0)
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			thread = append(thread, comment)
+			continue
+		}
+		if parent, ok := byID[*comment.ParentID]; ok {
+			parent.Replies = append(parent.Replies, comment)
+		}
+	}
+
+	return thread, nil
+}
+
+// UpdateComment changes the text of the identity's own comment
+func UpdateComment(id int, userID *int, guestUUID *string, text string) error {
+	comment, err := getOwnComment(id, userID, guestUUID)
+	if err != nil {
+		return err
+	}
+
+	text, err = validateCommentText(text)
+	if err != nil {
+		return err
+	}
+
+	return repository.UpdateCommentText(comment.ID, text)
+}
+
+// DeleteComment deletes the identity's own comment
+func DeleteComment(id int, userID *int, guestUUID *string) error {
+	comment, err := getOwnComment(id, userID, guestUUID)
+	if err != nil {
+		return err
+	}
+	return repository.DeleteComment(comment.ID)
+}
+
+// RemoveCommentByAdmin hides a comment from everyone but administrators
+func RemoveCommentByAdmin(id int, actor models.Actor, reason string) error {
+	return repository.RemoveComment(id, actor, reason)
+}
+
+// GetComment retrieves a single comment by ID
+func GetComment(id int) (models.Comment, error) {
+	return repository.GetComment(id)
+}
+
+func getOwnComment(id int, userID *int, guestUUID *string) (models.Comment, error) {
+	comment, err := repository.GetComment(id)
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	// Comments that were deleted or removed can't be changed any more
+	if comment.Status != models.CommentVisible {
+		return models.Comment{}, errs.ErrCommentNotFound
+	}
+
+	owner := (userID != nil && comment.UserID != nil && *userID == *comment.UserID) ||
+		(guestUUID != nil && comment.GuestUUID != nil && *guestUUID == *comment.GuestUUID)
+	if !owner {
+		return models.Comment{}, errs.ErrForbiddenComment
+	}
+
+	return comment, nil
+}
+
+func validateCommentText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errs.ErrCommentTextEmpty
+	}
+	if utf8.RuneCountInString(text) > configs.AppSettings.CommentParams.MaxLength {
+		return "", errs.ErrCommentTooLong
+	}
+	return text, nil
+}
+
+func isConfessionAuthor(confession models.Confession, userID *int, guestUUID *string) bool {
+	if confession.UserID != nil && userID != nil {
+		return *confession.UserID == *userID
+	}
+	if confession.GuestUUID != nil && guestUUID != nil {
+		return *confession.GuestUUID == *guestUUID
+	}
+	return false
+}
+
+// commentIdentity is the key an anonymous commenter's alias is stored under
+func commentIdentity(userID *int, guestUUID *string) string {
+	if userID != nil {
+		return "user:" + strconv.Itoa(*userID)
+	}
+	return "guest:" + *guestUUID
+}
+
+// aliasAnimal picks a random animal for a commenter's alias. It is only used
+// for the first comment in a thread, after that the stored alias is reused.
+// Deriving it from the identity would let anyone recompute who is behind an
+// alias, since user IDs are sequential and the animals are public.
+func aliasAnimal() string {
+	return models.CommentAliasAnimals[rand.IntN(len(models.CommentAliasAnimals))]
+}
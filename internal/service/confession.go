@@ -1,42 +1,238 @@
 package service
 
 import (
+	"github.com/hadisjane/confessly/internal/configs"
+	"github.com/hadisjane/confessly/internal/errs"
 	"github.com/hadisjane/confessly/internal/models"
 	"github.com/hadisjane/confessly/internal/repository"
 	"errors"
+	"slices"
+	"strconv"
+	"time"
 )
 
-// CreateConfession creates a new confession
-func CreateConfession(confession models.Confession) error {
+// CreateConfession creates a new confession and returns it with its id and
+// status. Under pre-moderation, when the content filter asks for it or when
+// it repeats a recent confession, it waits for approval as pending. A guest's
+// challenge is only spent once the confession is stored.
+func CreateConfession(confession models.Confession, challenge *models.SolvedChallenge) (models.Confession, error) {
 	// Validate that either UserID or GuestUUID is set, but not both
 	if (confession.UserID == nil && confession.GuestUUID == nil) || 
 	   (confession.UserID != nil && confession.GuestUUID != nil) {
-		return errors.New("confession must have either user ID or guest UUID")
+		return models.Confession{}, errors.New("confession must have either user ID or guest UUID")
 	}
-	
-	return repository.CreateConfession(confession)
+
+	moderate, err := filterConfession(&confession)
+	if err != nil {
+		return models.Confession{}, err
+	}
+
+	duplicate, err := checkDuplicate(&confession, 0)
+	if err != nil {
+		return models.Confession{}, err
+	}
+
+	confession.Status = models.ConfessionPublished
+	if moderate || duplicate || needsApproval(confession) {
+		confession.Status = models.ConfessionPending
+	}
+
+	id, err := repository.CreateConfession(confession, challenge)
+	if err != nil {
+		return models.Confession{}, err
+	}
+	confession.ID = id
+
+	return confession, nil
+}
+
+// GetOwnConfessions retrieves one page of the confessions of a user or guest,
+// including those that aren't public, optionally filtered by status
+func GetOwnConfessions(userID *int, guestUUID *string, status string, params models.PageParams) (models.ConfessionPage, error) {
+	if status != "" && !slices.Contains(models.ConfessionStatuses, status) {
+		return models.ConfessionPage{}, errs.ErrInvalidConfessionStatus
+	}
+
+	page, err := newPageQuery(params)
+	if err != nil {
+		return models.ConfessionPage{}, err
+	}
+
+	confessions, err := repository.GetOwnConfessions(userID, guestUUID, status, page)
+	if err != nil {
+		return models.ConfessionPage{}, err
+	}
+
+	return newConfessionPage(confessions, page), nil
 }
-// GetConfessions retrieves all confessions
-func GetAllConfessions() ([]models.Confession, error) {
-	return repository.GetAllConfessions()
+
+// GetAllConfessions retrieves one page of confessions
+func GetAllConfessions(params models.PageParams) (models.ConfessionPage, error) {
+	page, err := newPageQuery(params)
+	if err != nil {
+		return models.ConfessionPage{}, err
+	}
+
+	confessions, err := repository.GetAllConfessions(page)
+	if err != nil {
+		return models.ConfessionPage{}, err
+	}
+
+	result := newConfessionPage(confessions, page)
+
+	refs := make([]*models.Confession, 0, len(result.Confessions))
+	for i := range result.Confessions {
+		refs = append(refs, &result.Confessions[i])
+	}
+	if err := attachReactionCounts(refs); err != nil {
+		return models.ConfessionPage{}, err
+	}
+
+	return result, nil
 }
 
 // GetConfession retrieves a single confession by ID
 func GetConfession(id int) (models.Confession, error) {
-	return repository.GetConfession(id)
+	confession, err := repository.GetConfession(id)
+	if err != nil {
+		return models.Confession{}, err
+	}
+
+	if err := attachReactionCounts([]*models.Confession{&confession}); err != nil {
+		return models.Confession{}, err
+	}
+
+	return confession, nil
+}
+
+// CanViewConfession reports whether a confession is visible to the identity.
+// Confessions that aren't published are only shown to moderators and their author.
+func CanViewConfession(confession models.Confession, userID *int, guestUUID *string, moderator bool) bool {
+	return confession.Status == models.ConfessionPublished || moderator ||
+		isConfessionAuthor(confession, userID, guestUUID)
 }
 
-// UpdateConfession updates an existing confession
+// CheckConfessionOwner makes sure the identity wrote the confession, otherwise
+// it returns forbidden. Guests may only change their confessions within the
+// configured edit window.
+func CheckConfessionOwner(confession models.Confession, userID *int, guestUUID *string, forbidden error) error {
+	if !isConfessionAuthor(confession, userID, guestUUID) {
+		return forbidden
+	}
+
+	if guestUUID != nil {
+		window := time.Duration(configs.AppSettings.GuestParams.EditWindowMinutes) * time.Minute
+		if time.Since(confession.CreatedAt) > window {
+			return errs.ErrGuestEditWindowClosed
+		}
+	}
+
+	return nil
+}
+
+// UpdateConfession updates an existing confession. Under pre-moderation, when
+// the content filter asks for it or when it repeats a recent confession, an
+// edited confession has to be approved again, otherwise approving something harmless and editing it
+// afterwards would get around the queue.
 func UpdateConfession(id int, confession models.Confession) error {
+	moderate, err := filterConfession(&confession)
+	if err != nil {
+		return err
+	}
+
+	// Otherwise spam could be posted as something else and edited in
+	duplicate, err := checkDuplicate(&confession, id)
+	if err != nil {
+		return err
+	}
+
+	if (moderate || duplicate || needsApproval(confession)) &&
+		(confession.Status == models.ConfessionPublished || confession.Status == models.ConfessionRejected) {
+		confession.Status = models.ConfessionPending
+	}
 	return repository.UpdateConfession(id, confession)
 }
 
+// needsApproval reports whether the moderation mode holds the author's
+// confessions back until an admin approves them
+func needsApproval(confession models.Confession) bool {
+	switch configs.AppSettings.ModerationParams.Mode {
+	case models.ModerationPreAll:
+		return true
+	case models.ModerationPreGuests:
+		return confession.GuestUUID != nil
+	default:
+		return false
+	}
+}
+
+// requirePublished makes confessions that aren't public look missing
+func requirePublished(confessionID int) (models.Confession, error) {
+	confession, err := repository.GetConfession(confessionID)
+	if err != nil {
+		return models.Confession{}, err
+	}
+	if confession.Status != models.ConfessionPublished {
+		return models.Confession{}, errs.ErrConfessionNotFound
+	}
+	return confession, nil
+}
+
 // DeleteConfession deletes a confession by ID
 func DeleteConfession(id int) error {
 	return repository.DeleteConfession(id)
 }
 
-// SearchConfessionsByTitle searches confessions by title
-func SearchConfessionsByTitle(title string) ([]models.Confession, error) {
-	return repository.SearchConfessionsByTitle(title)
+// SearchConfessions runs a ranked full-text search and returns one page of results.
+// Results are sorted by relevance unless the client asks for new or old.
+func SearchConfessions(query string, params models.PageParams) (models.ConfessionSearchPage, error) {
+	page, err := newSortedPageQuery(params, models.SortRelevance, models.SortNew, models.SortOld)
+	if err != nil {
+		return models.ConfessionSearchPage{}, err
+	}
+
+	results, err := repository.SearchConfessions(query, page)
+	if err != nil {
+		return models.ConfessionSearchPage{}, err
+	}
+
+	var result models.ConfessionSearchPage
+	if !hasNextPage(len(results), page) {
+		result = models.ConfessionSearchPage{Confessions: results}
+	} else {
+		results = results[:page.Limit]
+		last := results[len(results)-1]
+		result = models.ConfessionSearchPage{
+			Confessions: results,
+			NextCursor: encodeRawCursor(models.Cursor{
+				CreatedAt: last.CreatedAt,
+				ID:        strconv.Itoa(last.ID),
+				Sort:      page.Sort,
+				Rank:      last.Rank,
+			}),
+		}
+	}
+
+	refs := make([]*models.Confession, 0, len(result.Confessions))
+	for i := range result.Confessions {
+		refs = append(refs, &result.Confessions[i].Confession)
+	}
+	if err := attachReactionCounts(refs); err != nil {
+		return models.ConfessionSearchPage{}, err
+	}
+
+	return result, nil
+}
+
+func newConfessionPage(confessions []models.Confession, page models.PageQuery) models.ConfessionPage {
+	if !hasNextPage(len(confessions), page) {
+		return models.ConfessionPage{Confessions: confessions}
+	}
+
+	confessions = confessions[:page.Limit]
+	last := confessions[len(confessions)-1]
+	return models.ConfessionPage{
+		Confessions: confessions,
+		NextCursor:  encodeCursor(last.CreatedAt, strconv.Itoa(last.ID), page.Sort),
+	}
 }
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"time"
+
+	"github.com/hadisjane/confessly/internal/configs"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+	"github.com/hadisjane/confessly/logger"
+	"github.com/hadisjane/confessly/utils"
+)
+
+// maxSimilarConfessions caps how many near-duplicates an admin gets at once
+const maxSimilarConfessions = 200
+
+// fingerprintBatchSize is how many old confessions are fingerprinted at a time
+const fingerprintBatchSize = 500
+
+// fingerprint is the SimHash of the title and text of a confession
+func fingerprint(confession models.Confession) int64 {
+	return int64(utils.SimHash(confession.Title + "\n" + confession.Text))
+}
+
+// checkDuplicate fingerprints a confession and looks for near-duplicates
+// among the recent ones, skipping the confession excludeID. Depending on the
+// configured action it returns ErrDuplicateConfession or reports that the
+// confession has to wait for approval.
+func checkDuplicate(confession *models.Confession, excludeID int) (bool, error) {
+	fp := fingerprint(*confession)
+	confession.Fingerprint = &fp
+
+	params := configs.AppSettings.DuplicateParams
+	if !params.Enabled || len([]rune(confession.Title+confession.Text)) < params.MinLength {
+		return false, nil
+	}
+
+	since := time.Now().UTC().Add(-time.Duration(params.WindowHours) * time.Hour)
+	similar, err := repository.FindSimilarConfessions(fp, params.MaxDistance, &since, excludeID, 1)
+	if err != nil {
+		return false, err
+	}
+	if len(similar) == 0 {
+		return false, nil
+	}
+
+	logger.Info.Printf("Confession %q is a near-duplicate of confession %d (distance %d), action %s",
+		confession.Title, similar[0].ID, similar[0].Distance, params.Action)
+
+	if params.Action == models.DuplicateReject {
+		return false, errs.ErrDuplicateConfession
+	}
+	return true, nil
+}
+
+// GetSimilarConfessions lists the near-duplicates of a confession from any
+// time, closest first, together with everyone who posted them
+func GetSimilarConfessions(id int) ([]models.SimilarConfession, []models.ConfessionAuthor, error) {
+	confession, err := repository.GetConfession(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	maxDistance := configs.AppSettings.DuplicateParams.MaxDistance
+	similar, err := repository.FindSimilarConfessions(fingerprint(confession), maxDistance, nil, id, maxSimilarConfessions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The campaign includes the author of the confession itself
+	authors := make([]models.ConfessionAuthor, 0)
+	index := make(map[string]int)
+	addAuthor := func(c models.Confession) {
+		// Authors whose account is gone are told apart by the name they posted under
+		key := "name:" + c.Username
+		if c.UserID != nil || c.GuestUUID != nil {
+			key = commentIdentity(c.UserID, c.GuestUUID)
+		}
+		if i, ok := index[key]; ok {
+			authors[i].Confessions++
+			return
+		}
+		index[key] = len(authors)
+		authors = append(authors, models.ConfessionAuthor{
+			UserID:      c.UserID,
+			GuestUUID:   c.GuestUUID,
+			Username:    c.Username,
+			Confessions: 1,
+		})
+	}
+	addAuthor(confession)
+	for _, s := range similar {
+		addAuthor(s.Confession)
+	}
+
+	return similar, authors, nil
+}
+
+// BackfillFingerprints fingerprints the confessions posted before
+// fingerprints were stored and returns how many there were
+func BackfillFingerprints() (int, error) {
+	total := 0
+	for {
+		confessions, err := repository.GetUnfingerprintedConfessions(fingerprintBatchSize)
+		if err != nil {
+			return total, err
+		}
+
+		for _, confession := range confessions {
+			if err := repository.SetConfessionFingerprint(confession.ID, fingerprint(confession)); err != nil {
+				return total, err
+			}
+		}
+		total += len(confessions)
+
+		if len(confessions) < fingerprintBatchSize {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/configs"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+	"github.com/hadisjane/confessly/logger"
+	"github.com/hadisjane/confessly/mailer"
+	"github.com/hadisjane/confessly/utils"
+)
+
+// ResendVerificationEmail sends the user a new verification link
+func ResendVerificationEmail(userID int) error {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return errs.ErrEmailAlreadyVerified
+	}
+
+	inBackground("verification email", func() error {
+		return sendVerificationEmail(user)
+	})
+	return nil
+}
+
+// VerifyEmail marks the address a verification token was sent to as verified
+func VerifyEmail(token string) error {
+	return repository.VerifyEmail(utils.HashToken(token))
+}
+
+// RequestPasswordReset mails a reset link if an account has this email. The
+// lookup and the sending happen after the response, so neither the answer nor
+// its timing tells whether the account exists.
+func RequestPasswordReset(email string) {
+	inBackground("password reset email", func() error {
+		user, err := repository.GetUserByEmail(strings.TrimSpace(email))
+		if errors.Is(err, errs.ErrNotFound) {
+			logger.Info.Println("Password reset requested for an unknown email")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		token, err := newEmailToken(user, models.EmailTokenReset,
+			time.Duration(configs.AppSettings.AuthParams.PasswordResetTtlMinutes)*time.Minute)
+		if err != nil {
+			return err
+		}
+
+		return mailer.Send(mailer.Message{
+			To:      user.Email,
+			Subject: configs.AppSettings.AppParams.ServerName + ": password reset",
+			Body: fmt.Sprintf("Hi %s,\n\nsomeone asked to reset the password of your account. "+
+				"To choose a new password, open this link within %d minutes:\n\n%s\n\n"+
+				"If it wasn't you, ignore this email, your password stays the same.\n",
+				user.Username, configs.AppSettings.AuthParams.PasswordResetTtlMinutes,
+				emailLink("reset-password", token)),
+		})
+	})
+}
+
+// ResetPassword sets a new password with a reset token and ends every
+// session of the user
+func ResetPassword(req models.ResetPasswordRequest) error {
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return err
+	}
+	return repository.ResetPassword(utils.HashToken(req.Token), hashedPassword)
+}
+
+func sendVerificationEmail(user models.User) error {
+	ttlHours := configs.AppSettings.AuthParams.EmailVerifyTtlHours
+	token, err := newEmailToken(user, models.EmailTokenVerify, time.Duration(ttlHours)*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	return mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: configs.AppSettings.AppParams.ServerName + ": confirm your email",
+		Body: fmt.Sprintf("Hi %s,\n\nplease confirm your email by opening this link within %d hours:\n\n%s\n\n"+
+			"If you didn't sign up, ignore this email.\n",
+			user.Username, ttlHours, emailLink("verify-email", token)),
+	})
+}
+
+// newEmailToken stores a fresh token for the user and returns it
+func newEmailToken(user models.User, purpose string, ttl time.Duration) (string, error) {
+	token, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = repository.CreateEmailToken(models.EmailToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		TokenHash: hash,
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// emailLink points to the page that posts the token back to the API
+func emailLink(page string, token string) string {
+	return strings.TrimRight(configs.AppSettings.MailParams.LinkBaseURL, "/") + "/" + page + "?token=" + token
+}
+
+// inBackground sends an email without holding up the request. Failures are
+// logged, the user can ask again.
+func inBackground(what string, send func() error) {
+	go func() {
+		if err := send(); err != nil {
+			logger.Error.Printf("Failed to send %s: %v", what, err)
+		}
+	}()
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+	"github.com/hadisjane/confessly/logger"
+	"github.com/hadisjane/confessly/utils"
+)
+
+// filterCacheTTL bounds how long a rule changed on another replica goes
+// unnoticed. Changes made through this process take effect right away.
+const filterCacheTTL = time.Minute
+
+const maxFilterPatternLength = 200
+
+// compiledFilter is an enabled rule ready to be matched
+type compiledFilter struct {
+	models.ContentFilter
+	word  string // the normalized literal
+	regex *regexp.Regexp
+}
+
+var (
+	filterCache   []compiledFilter
+	filterCacheAt time.Time
+	filterCacheMu sync.Mutex
+)
+
+// GetContentFilters lists every content filter rule
+func GetContentFilters() ([]models.ContentFilter, error) {
+	return repository.GetContentFilters()
+}
+
+// CreateContentFilter adds a rule. It is a literal that rejects text unless
+// the request says otherwise.
+func CreateContentFilter(req models.ContentFilterRequest, actor models.Actor) (models.ContentFilter, error) {
+	filter := models.ContentFilter{
+		Kind:    models.FilterLiteral,
+		Action:  models.FilterReject,
+		Enabled: true,
+	}
+	applyFilterRequest(&filter, req)
+
+	if _, err := compileFilter(filter); err != nil {
+		return models.ContentFilter{}, err
+	}
+
+	id, err := repository.CreateContentFilter(filter, actor)
+	if err != nil {
+		return models.ContentFilter{}, err
+	}
+	invalidateFilters()
+
+	return repository.GetContentFilter(id)
+}
+
+// UpdateContentFilter changes the fields of a rule that the request sets
+func UpdateContentFilter(id int, req models.ContentFilterRequest, actor models.Actor) (models.ContentFilter, error) {
+	filter, err := repository.GetContentFilter(id)
+	if err != nil {
+		return models.ContentFilter{}, err
+	}
+	applyFilterRequest(&filter, req)
+
+	if _, err := compileFilter(filter); err != nil {
+		return models.ContentFilter{}, err
+	}
+
+	if err := repository.UpdateContentFilter(filter, actor); err != nil {
+		return models.ContentFilter{}, err
+	}
+	invalidateFilters()
+
+	return repository.GetContentFilter(id)
+}
+
+// DeleteContentFilter removes a rule
+func DeleteContentFilter(id int, actor models.Actor) error {
+	if err := repository.DeleteContentFilter(id, actor); err != nil {
+		return err
+	}
+	invalidateFilters()
+	return nil
+}
+
+func applyFilterRequest(filter *models.ContentFilter, req models.ContentFilterRequest) {
+	if req.Pattern != nil {
+		filter.Pattern = strings.TrimSpace(*req.Pattern)
+	}
+	if req.Kind != nil {
+		filter.Kind = *req.Kind
+	}
+	if req.Action != nil {
+		filter.Action = *req.Action
+	}
+	if req.Enabled != nil {
+		filter.Enabled = *req.Enabled
+	}
+}
+
+// compileFilter validates a rule and prepares it for matching
+func compileFilter(filter models.ContentFilter) (compiledFilter, error) {
+	if !slices.Contains(models.FilterKinds, filter.Kind) {
+		return compiledFilter{}, errs.ErrInvalidFilterKind
+	}
+	if !slices.Contains(models.FilterActions, filter.Action) {
+		return compiledFilter{}, errs.ErrInvalidFilterAction
+	}
+	if filter.Pattern == "" || len([]rune(filter.Pattern)) > maxFilterPatternLength {
+		return compiledFilter{}, errs.ErrInvalidFilterPattern
+	}
+
+	compiled := compiledFilter{ContentFilter: filter}
+	if filter.Kind == models.FilterRegex {
+		// Text is lowercased before matching, so the pattern is case-insensitive too
+		regex, err := regexp.Compile("(?i)" + filter.Pattern)
+		if err != nil {
+			return compiledFilter{}, errs.ErrInvalidFilterPattern
+		}
+		compiled.regex = regex
+	} else {
+		compiled.word = utils.NormalizePattern(filter.Pattern)
+		if compiled.word == "" {
+			return compiledFilter{}, errs.ErrInvalidFilterPattern
+		}
+	}
+	return compiled, nil
+}
+
+// activeFilters returns the enabled rules, loading them when the cache was
+// invalidated or has expired
+func activeFilters() ([]compiledFilter, error) {
+	filterCacheMu.Lock()
+	defer filterCacheMu.Unlock()
+
+	if filterCache != nil && time.Since(filterCacheAt) < filterCacheTTL {
+		return filterCache, nil
+	}
+
+	filters, err := repository.GetContentFilters()
+	if err != nil {
+		return nil, err
+	}
+
+	compiled := make([]compiledFilter, 0, len(filters))
+	for _, filter := range filters {
+		if !filter.Enabled {
+			continue
+		}
+		c, err := compileFilter(filter)
+		if err != nil {
+			logger.Warn.Printf("Skipping content filter %d: %v", filter.ID, err)
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+
+	filterCache, filterCacheAt = compiled, time.Now()
+	return filterCache, nil
+}
+
+func invalidateFilters() {
+	filterCacheMu.Lock()
+	defer filterCacheMu.Unlock()
+	filterCache = nil
+}
+
+// filterText checks text against the content filter rules. It returns the
+// text with masked matches and whether a rule asks for moderation, or
+// ErrContentRejected. Every match is logged with its rule for tuning.
+func filterText(target string, text string) (string, bool, error) {
+	filters, err := activeFilters()
+	if err != nil {
+		return "", false, err
+	}
+
+	normalized := utils.NormalizeText(text)
+	var masked [][2]int
+	var moderate, rejected bool
+
+	for _, filter := range filters {
+		var spans [][2]int
+		if filter.regex != nil {
+			spans = normalized.FindRegexp(filter.regex)
+		} else {
+			spans = normalized.FindWord(filter.word)
+		}
+		if len(spans) == 0 {
+			continue
+		}
+
+		logger.Info.Printf("Content filter %d matched %s %d time(s), action %s", filter.ID, target, len(spans), filter.Action)
+
+		switch filter.Action {
+		case models.FilterReject:
+			rejected = true
+		case models.FilterMask:
+			masked = append(masked, spans...)
+		case models.FilterModerate:
+			moderate = true
+		}
+	}
+
+	if rejected {
+		return "", false, errs.ErrContentRejected
+	}
+	if len(masked) > 0 {
+		text = normalized.Mask(masked)
+	}
+	return text, moderate, nil
+}
+
+// filterConfession runs the title and text of a confession through the
+// content filter and reports whether it has to wait for approval
+func filterConfession(confession *models.Confession) (bool, error) {
+	title, moderateTitle, err := filterText("confession title", confession.Title)
+	if err != nil {
+		return false, err
+	}
+	text, moderateText, err := filterText("confession text", confession.Text)
+	if err != nil {
+		return false, err
+	}
+
+	confession.Title, confession.Text = title, text
+	return moderateTitle || moderateText, nil
+}
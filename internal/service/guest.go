@@ -1,22 +1,80 @@
 package service
 
 import (
+	"errors"
+
+	"github.com/hadisjane/confessly/internal/errs"
 	"github.com/hadisjane/confessly/internal/models"
 	"github.com/hadisjane/confessly/internal/repository"
+
+	"github.com/google/uuid"
 )
 
 func CreateGuestUser(guestUser models.GuestUser) error {
 	return repository.CreateGuestUser(guestUser)
 }
 
-func GetGuestUsers() ([]models.GuestUser, error) {
-	return repository.GetGuestUsers()
+func GetGuestUsers(params models.PageParams) (models.GuestUserPage, error) {
+	page, err := newUUIDPageQuery(params)
+	if err != nil {
+		return models.GuestUserPage{}, err
+	}
+
+	guestUsers, err := repository.GetGuestUsers(page)
+	if err != nil {
+		return models.GuestUserPage{}, err
+	}
+
+	if !hasNextPage(len(guestUsers), page) {
+		return models.GuestUserPage{GuestUsers: guestUsers}, nil
+	}
+
+	guestUsers = guestUsers[:page.Limit]
+	last := guestUsers[len(guestUsers)-1]
+	return models.GuestUserPage{
+		GuestUsers: guestUsers,
+		NextCursor: encodeCursor(last.CreatedAt, last.UUID, page.Sort),
+	}, nil
 }
 
 func GetGuestUser(uuid string) (models.GuestUser, error) {
 	return repository.GetGuestUser(uuid)
 }
 
-func IsGuestBanned(uuid string) (bool, error) {
-	return repository.IsGuestBanned(uuid)
-}
\ No newline at end of file
+// UpgradeLegacyGuestCookie reports whether a bare UUID cookie may be traded
+// for a signed one. Each guest can do that only once.
+func UpgradeLegacyGuestCookie(uuid string) (bool, error) {
+	return repository.UpgradeLegacyGuestCookie(uuid)
+}
+
+// ClaimGuest moves the confessions of the guest identity guestUUID to the
+// user and returns how many were moved
+func ClaimGuest(guestUUID string, userID int) (int64, error) {
+	if err := checkGuestClaimable(guestUUID); err != nil {
+		return 0, err
+	}
+	return repository.ClaimGuest(guestUUID, userID)
+}
+
+// checkGuestClaimable refuses unknown, already claimed and banned guests
+// before anything is written
+func checkGuestClaimable(guestUUID string) error {
+	if _, err := uuid.Parse(guestUUID); err != nil {
+		return errs.ErrNoGuestToClaim
+	}
+
+	guest, err := repository.GetGuestUser(guestUUID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return errs.ErrNoGuestToClaim
+		}
+		return err
+	}
+	if guest.MergedAt != nil {
+		return errs.ErrGuestAlreadyClaimed
+	}
+
+	// A posting ban counts too, or claiming would let the guest post again
+	// from the new account
+	return CheckGuestBan(guestUUID, models.BanScopePosting)
+}
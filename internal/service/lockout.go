@@ -0,0 +1,242 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/configs"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+	"github.com/hadisjane/confessly/logger"
+	"github.com/hadisjane/confessly/mailer"
+	"github.com/hadisjane/confessly/utils"
+)
+
+// loginFailurePruneInterval is how often forgotten failures are dropped
+const loginFailurePruneInterval = time.Minute
+
+var (
+	lastLoginFailurePrune   time.Time
+	lastLoginFailurePruneMu sync.Mutex
+)
+
+// dummyPasswordHash is checked against when there is no real hash to check,
+// so a refused or unknown username takes as long as a wrong password
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := utils.HashPassword("confessly-dummy-password")
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// authenticate checks a username and password the way every login does.
+// ip has to come from c.ClientIP(), which only believes forwarding headers
+// from the trusted proxies, or anyone could dodge or frame an IP lockout.
+// Failures are counted per username and per IP, and while either is in
+// backoff or locked out the password isn't checked at all. Every failure,
+// including an unknown username, is ErrIncorrectUsernameOrPassword and costs
+// one bcrypt comparison, so neither the answer nor its timing tells them apart.
+func authenticate(username string, password string, ip string) (models.User, error) {
+	blocked, err := loginBlocked(username, ip)
+	if err != nil {
+		return models.User{}, err
+	}
+	if blocked {
+		utils.VerifyPassword(dummyPasswordHash(), password)
+		return models.User{}, errs.ErrIncorrectUsernameOrPassword
+	}
+
+	user, err := repository.GetUserByUsername(username)
+	if err != nil && !errors.Is(err, errs.ErrNotFound) {
+		return models.User{}, err
+	}
+
+	if err != nil {
+		utils.VerifyPassword(dummyPasswordHash(), password)
+	} else if utils.VerifyPassword(user.Password, password) == nil {
+		if err := clearLoginFailures(username); err != nil {
+			logger.Error.Printf("Failed to clear failed logins of %q: %v", username, err)
+		}
+		return user, nil
+	}
+
+	key, userID := models.LockoutUnknownUsername, (*int)(nil)
+	if user.ID != 0 {
+		key, userID = username, &user.ID
+	}
+	if err := recordLoginFailure(key, userID, ip); err != nil {
+		return models.User{}, err
+	}
+	return models.User{}, errs.ErrIncorrectUsernameOrPassword
+}
+
+// loginBlocked reports whether the username or the IP may not try to log
+// in right now
+func loginBlocked(username string, ip string) (bool, error) {
+	if !configs.AppSettings.LockoutParams.Enabled {
+		return false, nil
+	}
+	blocks, err := repository.GetLoginBlocks(username, ip, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	return len(blocks) > 0, nil
+}
+
+// recordLoginFailure counts a failed login against the username and the IP
+// and mails the owner of the account when it gets locked. Usernames without
+// an account all count against LockoutUnknownUsername.
+func recordLoginFailure(username string, userID *int, ip string) error {
+	params := configs.AppSettings.LockoutParams
+	if !params.Enabled {
+		return nil
+	}
+	now := time.Now().UTC()
+	pruneLoginFailures(now)
+
+	var locked *models.LoginFailure
+	err := repository.RecordLoginFailure(models.LockoutAccount, username, func(current *models.LoginFailure) models.LoginFailure {
+		failure := nextLoginFailure(current, params.AccountMaxFailures, now)
+		failure.UserID = userID
+		if failure.LockedAt != nil && failure.LockedAt.Equal(now) {
+			locked = &failure
+		}
+		return failure
+	})
+	if err != nil {
+		return err
+	}
+
+	err = repository.RecordLoginFailure(models.LockoutIP, ip, func(current *models.LoginFailure) models.LoginFailure {
+		return nextLoginFailure(current, params.IPMaxFailures, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case locked == nil:
+	case userID == nil:
+		logger.Warn.Printf("%d failed logins with unknown usernames within the window, the last from %s",
+			locked.Failures, ip)
+	default:
+		logger.Warn.Printf("Logins as %q are locked until %s after %d failures, the last from %s",
+			username, locked.BlockedUntil.Format(time.RFC3339), locked.Failures, ip)
+		inBackground("lockout email", func() error {
+			return sendLockoutEmail(*userID, *locked, ip)
+		})
+	}
+	return nil
+}
+
+// nextLoginFailure counts one more failure. Below maxFailures the next
+// attempt has to wait a delay that doubles with every failure; from then on
+// logins are locked for the lockout time, again after each further failure.
+// Failures older than the window are forgotten first.
+func nextLoginFailure(current *models.LoginFailure, maxFailures int, now time.Time) models.LoginFailure {
+	params := configs.AppSettings.LockoutParams
+	window := time.Duration(params.WindowMinutes) * time.Minute
+
+	failure := models.LoginFailure{CreatedAt: now}
+	if current != nil && now.Sub(current.LastFailureAt) < window {
+		failure = *current
+	}
+	failure.Failures++
+	failure.LastFailureAt = now
+
+	if failure.Failures >= maxFailures {
+		if failure.LockedAt == nil {
+			failure.LockedAt = &now
+		}
+		failure.BlockedUntil = now.Add(time.Duration(params.LockMinutes) * time.Minute)
+		return failure
+	}
+
+	maxDelay := time.Duration(params.MaxDelaySeconds) * time.Second
+	delay := time.Duration(params.BaseDelaySeconds) * time.Second
+	for i := 1; i < failure.Failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	failure.BlockedUntil = now.Add(min(delay, maxDelay))
+	return failure
+}
+
+func clearLoginFailures(username string) error {
+	if !configs.AppSettings.LockoutParams.Enabled {
+		return nil
+	}
+	return repository.ClearLoginFailures(models.LockoutAccount, username)
+}
+
+// pruneLoginFailures drops records that no longer block and whose failures
+// are forgotten, at most once per loginFailurePruneInterval
+func pruneLoginFailures(now time.Time) {
+	lastLoginFailurePruneMu.Lock()
+	if now.Sub(lastLoginFailurePrune) < loginFailurePruneInterval {
+		lastLoginFailurePruneMu.Unlock()
+		return
+	}
+	lastLoginFailurePrune = now
+	lastLoginFailurePruneMu.Unlock()
+
+	window := time.Duration(configs.AppSettings.LockoutParams.WindowMinutes) * time.Minute
+	// Failing to prune only leaves some stale rows behind
+	go repository.PruneLoginFailures(now.Add(-window), now)
+}
+
+func sendLockoutEmail(userID int, failure models.LoginFailure, ip string) error {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	return mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: configs.AppSettings.AppParams.ServerName + ": your account has been locked",
+		Body: fmt.Sprintf("Hi %s,\n\nafter %d failed login attempts, the last one from %s, logins to your account "+
+			"are locked until %s UTC. Until then even the right password is refused.\n\n"+
+			"If it wasn't you, someone is guessing your password. Consider choosing a stronger one "+
+			"and turning on two-factor authentication.\n",
+			user.Username, failure.Failures, ip, failure.BlockedUntil.Format("2006-01-02 15:04")),
+	})
+}
+
+// GetLoginLockouts lists the accounts and IPs that can't log in right now,
+// optionally of one scope
+func GetLoginLockouts(scope string, params models.PageParams) (models.LoginFailurePage, error) {
+	if scope != "" && scope != models.LockoutAccount && scope != models.LockoutIP {
+		return models.LoginFailurePage{}, errs.ErrInvalidLockoutScope
+	}
+
+	page, err := newPageQuery(params)
+	if err != nil {
+		return models.LoginFailurePage{}, err
+	}
+
+	lockouts, err := repository.GetLoginLockouts(scope, time.Now().UTC(), page)
+	if err != nil {
+		return models.LoginFailurePage{}, err
+	}
+
+	if !hasNextPage(len(lockouts), page) {
+		return models.LoginFailurePage{Lockouts: lockouts}, nil
+	}
+
+	lockouts = lockouts[:page.Limit]
+	last := lockouts[len(lockouts)-1]
+	return models.LoginFailurePage{
+		Lockouts:   lockouts,
+		NextCursor: encodeCursor(last.CreatedAt, strconv.Itoa(last.ID), page.Sort),
+	}, nil
+}
+
+// ClearLoginLockout forgets the failures of an account or IP, so it can log
+// in again right away
+func ClearLoginLockout(id int, actor models.Actor, reason string) error {
+	return repository.ClearLoginLockout(id, actor, reason)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+)
+
+// GetModerationQueue returns one page of confessions waiting for approval,
+// oldest first unless the admin asks for new
+func GetModerationQueue(params models.PageParams) (models.ConfessionPage, error) {
+	page, err := newSortedPageQuery(params, models.SortOld, models.SortNew)
+	if err != nil {
+		return models.ConfessionPage{}, err
+	}
+
+	confessions, err := repository.GetPendingConfessions(page)
+	if err != nil {
+		return models.ConfessionPage{}, err
+	}
+
+	return newConfessionPage(confessions, page), nil
+}
+
+// GetAutoHiddenConfessions returns one page of the confessions hidden by
+// reports, oldest first unless the admin asks for new
+func GetAutoHiddenConfessions(params models.PageParams) (models.ConfessionPage, error) {
+	page, err := newSortedPageQuery(params, models.SortOld, models.SortNew)
+	if err != nil {
+		return models.ConfessionPage{}, err
+	}
+
+	confessions, err := repository.GetAutoHiddenConfessions(page)
+	if err != nil {
+		return models.ConfessionPage{}, err
+	}
+
+	return newConfessionPage(confessions, page), nil
+}
+
+// ApproveConfession publishes a pending confession. Rejected and hidden
+// confessions can be published this way too.
+func ApproveConfession(id int, actor models.Actor, reason string) error {
+	from := []string{models.ConfessionPending, models.ConfessionRejected, models.ConfessionHidden}
+	return repository.ModerateConfession(id, from, models.ConfessionPublished, models.AuditApproveConfession, actor, reason)
+}
+
+// RejectConfession turns down a pending confession. The author can see the reason.
+func RejectConfession(id int, actor models.Actor, reason string) error {
+	from := []string{models.ConfessionPending}
+	return repository.ModerateConfession(id, from, models.ConfessionRejected, models.AuditRejectConfession, actor, reason)
+}
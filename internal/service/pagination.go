@@ -0,0 +1,110 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/configs"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// newPageQuery validates the client's page params for a list sorted by date
+// and keyed by an integer id
+func newPageQuery(params models.PageParams) (models.PageQuery, error) {
+	return newSortedPageQuery(params, models.SortNew, models.SortOld)
+}
+
+// newUUIDPageQuery validates the client's page params for a list sorted by
+// date and keyed by a UUID
+func newUUIDPageQuery(params models.PageParams) (models.PageQuery, error) {
+	return newKeyedPageQuery(params, validUUIDCursorID, models.SortNew, models.SortOld)
+}
+
+// newSortedPageQuery validates the client's page params for a list keyed by an
+// integer id. The first of sorts is the default.
+func newSortedPageQuery(params models.PageParams, sorts ...string) (models.PageQuery, error) {
+	return newKeyedPageQuery(params, validIntCursorID, sorts...)
+}
+
+// newKeyedPageQuery validates the client's page params, applies the server-side
+// limit cap and decodes the cursor, whose id has to pass validID before it
+// reaches the query. The first of sorts is the default.
+func newKeyedPageQuery(params models.PageParams, validID func(id string) bool, sorts ...string) (models.PageQuery, error) {
+	cfg := configs.AppSettings.PageParams
+
+	page := models.PageQuery{Limit: params.Limit, Sort: params.Sort}
+
+	if page.Limit < 0 {
+		return models.PageQuery{}, errs.ErrInvalidLimit
+	}
+	if page.Limit == 0 {
+		page.Limit = cfg.DefaultLimit
+	}
+	if page.Limit > cfg.MaxLimit {
+		page.Limit = cfg.MaxLimit
+	}
+
+	if page.Sort == "" {
+		page.Sort = sorts[0]
+	}
+	if !slices.Contains(sorts, page.Sort) {
+		return models.PageQuery{}, errs.ErrInvalidSort
+	}
+
+	if params.Cursor != "" {
+		cursor, err := decodeCursor(params.Cursor)
+		if err != nil || cursor.Sort != page.Sort || !validID(cursor.ID) {
+			return models.PageQuery{}, errs.ErrInvalidCursor
+		}
+		page.After = &cursor
+	}
+
+	return page, nil
+}
+
+// hasNextPage reports whether the repository returned the extra row
+// that limitClause asks for
+func hasNextPage(n int, page models.PageQuery) bool {
+	return n > page.Limit
+}
+
+func encodeCursor(createdAt time.Time, id string, sort string) string {
+	return encodeRawCursor(models.Cursor{CreatedAt: createdAt, ID: id, Sort: sort})
+}
+
+func encodeRawCursor(cursor models.Cursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(token string) (models.Cursor, error) {
+	var cursor models.Cursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return cursor, err
+	}
+	if cursor.ID == "" || cursor.CreatedAt.IsZero() {
+		return cursor, errs.ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+func validIntCursorID(id string) bool {
+	_, err := strconv.Atoi(id)
+	return err == nil
+}
+
+func validUUIDCursorID(id string) bool {
+	return uuid.Validate(id) == nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/configs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+)
+
+// rateLimitPruneInterval is how often idle buckets are dropped
+const rateLimitPruneInterval = time.Minute
+
+var (
+	memoryBuckets   = make(map[string]*models.RateLimitBucket)
+	memoryBucketsMu sync.Mutex
+	lastPrune       time.Time
+	lastPruneMu     sync.Mutex
+)
+
+// TakeRateLimit takes a token from the bucket of the caller under the named
+// policy. The caller is the user, the guest or the client IP, depending on
+// what the policy counts by. Policies that are not configured don't limit.
+func TakeRateLimit(name string, userID *int, guestUUID *string, ip string) (models.RateLimitResult, error) {
+	params := configs.AppSettings.RateLimitParams
+	policy, ok := params.Policies[name]
+	if !params.Enabled || !ok {
+		return models.RateLimitResult{Allowed: true}, nil
+	}
+
+	key := name + ":" + rateLimitSubject(policy, userID, guestUUID, ip)
+	now := time.Now().UTC()
+	pruneRateLimits(now)
+
+	if params.Backend == models.RateLimitPostgres {
+		var result models.RateLimitResult
+		err := repository.TakeRateLimitToken(key, func(bucket *models.RateLimitBucket) models.RateLimitBucket {
+			if bucket == nil {
+				bucket = &models.RateLimitBucket{Tokens: float64(policy.Requests), UpdatedAt: now}
+			}
+			result = takeToken(bucket, policy, now)
+			return *bucket
+		})
+		return result, err
+	}
+
+	memoryBucketsMu.Lock()
+	defer memoryBucketsMu.Unlock()
+
+	bucket, ok := memoryBuckets[key]
+	if !ok {
+		bucket = &models.RateLimitBucket{Tokens: float64(policy.Requests), UpdatedAt: now}
+		memoryBuckets[key] = bucket
+	}
+	return takeToken(bucket, policy, now), nil
+}
+
+func rateLimitSubject(policy models.RateLimitPolicy, userID *int, guestUUID *string, ip string) string {
+	if policy.Key == models.RateLimitByIdentity {
+		if userID != nil {
+			return "user:" + strconv.Itoa(*userID)
+		}
+		if guestUUID != nil {
+			return "guest:" + *guestUUID
+		}
+	}
+	return "ip:" + ip
+}
+
+// takeToken refills the bucket for the time since it was last used and takes
+// one token from it if there is one
+func takeToken(bucket *models.RateLimitBucket, policy models.RateLimitPolicy, now time.Time) models.RateLimitResult {
+	capacity := float64(policy.Requests)
+	rate := capacity / float64(policy.PerSeconds)
+
+	elapsed := math.Max(0, now.Sub(bucket.UpdatedAt).Seconds())
+	bucket.Tokens = math.Min(capacity, bucket.Tokens+elapsed*rate)
+	bucket.UpdatedAt = now
+
+	result := models.RateLimitResult{
+		Limit:  policy.Requests,
+		Window: policy.PerSeconds,
+	}
+	if bucket.Tokens >= 1 {
+		bucket.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsDuration((1 - bucket.Tokens) / rate)
+	}
+	result.Remaining = int(bucket.Tokens)
+	result.Reset = secondsDuration((capacity - bucket.Tokens) / rate)
+
+	return result
+}
+
+// pruneRateLimits drops buckets that have been idle for longer than the
+// longest window, at most once per rateLimitPruneInterval
+func pruneRateLimits(now time.Time) {
+	lastPruneMu.Lock()
+	if now.Sub(lastPrune) < rateLimitPruneInterval {
+		lastPruneMu.Unlock()
+		return
+	}
+	lastPrune = now
+	lastPruneMu.Unlock()
+
+	params := configs.AppSettings.RateLimitParams
+	longest := 0
+	for _, policy := range params.Policies {
+		longest = max(longest, policy.PerSeconds)
+	}
+	before := now.Add(-time.Duration(longest) * time.Second)
+
+	if params.Backend == models.RateLimitPostgres {
+		// Failing to prune only leaves some idle rows behind
+		go repository.PruneRateLimits(before)
+		return
+	}
+
+	memoryBucketsMu.Lock()
+	defer memoryBucketsMu.Unlock()
+	for key, bucket := range memoryBuckets {
+		if bucket.UpdatedAt.Before(before) {
+			delete(memoryBuckets, key)
+		}
+	}
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/models"
+)
+
+func TestTakeToken(t *testing.T) {
+	// One token every 6 seconds
+	policy := models.RateLimitPolicy{Requests: 10, PerSeconds: 60}
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name      string
+		bucket    models.RateLimitBucket
+		allowed   bool
+		remaining int
+		retry     time.Duration
+		reset     time.Duration
+	}{
+		{"full bucket", models.RateLimitBucket{Tokens: 10, UpdatedAt: now}, true, 9, 0, 6 * time.Second},
+		{"empty bucket", models.RateLimitBucket{Tokens: 0, UpdatedAt: now}, false, 0, 6 * time.Second, time.Minute},
+		{"refilled meanwhile", models.RateLimitBucket{Tokens: 0, UpdatedAt: now.Add(-12 * time.Second)}, true, 1, 0, 54 * time.Second},
+		{"refill is capped", models.RateLimitBucket{Tokens: 5, UpdatedAt: now.Add(-time.Hour)}, true, 9, 0, 6 * time.Second},
+		{"half a token", models.RateLimitBucket{Tokens: 0.5, UpdatedAt: now}, false, 0, 3 * time.Second, 57 * time.Second},
+		{"clock went back", models.RateLimitBucket{Tokens: 0, UpdatedAt: now.Add(time.Minute)}, false, 0, 6 * time.Second, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := tt.bucket
+			got := takeToken(&bucket, policy, now)
+
+			if got.Allowed != tt.allowed || got.Remaining != tt.remaining {
+				t.Errorf("allowed, remaining = %v, %d, want %v, %d", got.Allowed, got.Remaining, tt.allowed, tt.remaining)
+			}
+			if !closeTo(got.RetryAfter, tt.retry) || !closeTo(got.Reset, tt.reset) {
+				t.Errorf("retry after, reset = %v, %v, want %v, %v", got.RetryAfter, got.Reset, tt.retry, tt.reset)
+			}
+			if got.Limit != policy.Requests || got.Window != policy.PerSeconds {
+				t.Errorf("limit, window = %d, %d, want %d, %d", got.Limit, got.Window, policy.Requests, policy.PerSeconds)
+			}
+			if !bucket.UpdatedAt.Equal(now) {
+				t.Errorf("bucket updated at %v, want %v", bucket.UpdatedAt, now)
+			}
+		})
+	}
+}
+
+func TestTakeTokenDrainsBucket(t *testing.T) {
+	policy := models.RateLimitPolicy{Requests: 3, PerSeconds: 60}
+	now := time.Unix(1700000000, 0)
+	bucket := models.RateLimitBucket{Tokens: 3, UpdatedAt: now}
+
+	for i := 0; i < 3; i++ {
+		if !takeToken(&bucket, policy, now).Allowed {
+			t.Fatalf("request %d refused", i+1)
+		}
+	}
+	if takeToken(&bucket, policy, now).Allowed {
+		t.Error("request 4 allowed from an empty bucket")
+	}
+	if !takeToken(&bucket, policy, now.Add(20*time.Second)).Allowed {
+		t.Error("request refused after a token came back")
+	}
+}
+
+// closeTo compares durations computed from float seconds
+func closeTo(got, want time.Duration) bool {
+	d := got - want
+	return d > -time.Millisecond && d < time.Millisecond
+}
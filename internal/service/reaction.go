@@ -0,0 +1,98 @@
+package service
+
+import (
+	"slices"
+
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+)
+
+// AddReaction adds the identity's reaction of one kind to a confession
+func AddReaction(reaction models.Reaction) error {
+	if err := validateReaction(reaction); err != nil {
+		return err
+	}
+	if _, err := requirePublished(reaction.ConfessionID); err != nil {
+		return err
+	}
+	return repository.AddReaction(reaction)
+}
+
+// RemoveReaction removes the identity's reaction of one kind from a confession
+func RemoveReaction(reaction models.Reaction) error {
+	if err := validateReaction(reaction); err != nil {
+		return err
+	}
+	if _, err := requirePublished(reaction.ConfessionID); err != nil {
+		return err
+	}
+	return repository.RemoveReaction(reaction)
+}
+
+// GetReactionSummary returns the reaction counts of a confession, the kinds
+// the identity reacted with and, when withReactors is set, who reacted
+func GetReactionSummary(confessionID int, userID *int, guestUUID *string, withReactors bool) (models.ReactionSummary, error) {
+	counts, err := repository.GetReactionCounts([]int{confessionID})
+	if err != nil {
+		return models.ReactionSummary{}, err
+	}
+
+	summary := models.ReactionSummary{
+		Counts:      withAllKinds(counts[confessionID]),
+		MyReactions: []string{},
+	}
+
+	if userID != nil || guestUUID != nil {
+		summary.MyReactions, err = repository.GetMyReactions(confessionID, userID, guestUUID)
+		if err != nil {
+			return models.ReactionSummary{}, err
+		}
+	}
+
+	if withReactors {
+		summary.Reactors, err = repository.GetReactors(confessionID)
+		if err != nil {
+			return models.ReactionSummary{}, err
+		}
+	}
+
+	return summary, nil
+}
+
+// attachReactionCounts fills in the aggregated reaction counts of confessions
+func attachReactionCounts(confessions []*models.Confession) error {
+	ids := make([]int, 0, len(confessions))
+	for _, confession := range confessions {
+		ids = append(ids, confession.ID)
+	}
+
+	counts, err := repository.GetReactionCounts(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, confession := range confessions {
+		confession.Reactions = withAllKinds(counts[confession.ID])
+	}
+	return nil
+}
+
+// withAllKinds makes sure every reaction kind is present, with zero counts
+func withAllKinds(counts map[string]int) map[string]int {
+	all := make(map[string]int, len(models.ReactionKinds))
+	for _, kind := range models.ReactionKinds {
+		all[kind] = counts[kind]
+	}
+	return all
+}
+
+func validateReaction(reaction models.Reaction) error {
+	if !slices.Contains(models.ReactionKinds, reaction.Kind) {
+		return errs.ErrInvalidReactionKind
+	}
+	if (reaction.UserID == nil) == (reaction.GuestUUID == nil) {
+		return errs.ErrUnauthorized
+	}
+	return nil
+}
@@ -1,11 +1,67 @@
 package service
 
 import (
+	"time"
+
+	"github.com/hadisjane/confessly/internal/configs"
 	"github.com/hadisjane/confessly/internal/models"
 	"github.com/hadisjane/confessly/internal/repository"
 )
 
 func CreateReport(report models.Report) error {
-	return repository.CreateReport(report)
+	// A report on a comment is filed under the comment's confession
+	if report.CommentID != nil {
+		comment, err := repository.GetComment(*report.CommentID)
+		if err != nil {
+			return err
+		}
+		report.ConfessionID = &comment.ConfessionID
+	}
+
+	// Reports don't go through moderation, so only reject and mask rules apply
+	reason, _, err := filterText("report reason", report.Reason)
+	if err != nil {
+		return err
+	}
+	report.Reason = reason
+
+	var shouldHide func([]models.ReporterRecord) bool
+	if configs.AppSettings.AutoHideParams.Enabled {
+		shouldHide = shouldAutoHide
+	}
+
+	return repository.CreateReport(report, shouldHide)
+}
+
+// shouldAutoHide reports whether the open reports on a confession match one
+// of the auto-hide rules
+func shouldAutoHide(reporters []models.ReporterRecord) bool {
+	for _, rule := range configs.AppSettings.AutoHideParams.Rules {
+		window := time.Duration(rule.WindowMinutes) * time.Minute
+
+		var weight float64
+		for _, reporter := range reporters {
+			if time.Since(reporter.ReportedAt) <= window {
+				weight += reporterWeight(reporter)
+			}
+		}
+		if weight >= rule.Reporters {
+			return true
+		}
+	}
+	return false
+}
+
+// reporterWeight is the share of a reporter's past closed reports that were
+// acted on, smoothed so that a reporter without history weighs 1. New accounts
+// weigh less so that a burst of fresh accounts can't hide a confession.
+func reporterWeight(reporter models.ReporterRecord) float64 {
+	params := configs.AppSettings.AutoHideParams
+
+	weight := float64(reporter.Resolved+1) / float64(reporter.Resolved+reporter.Dismissed+1)
+	if time.Since(reporter.AccountCreatedAt) < time.Duration(params.NewAccountHours)*time.Hour {
+		weight *= params.NewAccountWeight
+	}
+	return weight
 }
 	
\ No newline at end of file
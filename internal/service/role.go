@@ -0,0 +1,15 @@
+package service
+
+import (
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+)
+
+// SetUserRole promotes or demotes a user
+func SetUserRole(userID int, req models.RoleRequest, actor models.Actor) error {
+	if !models.IsRole(req.Role) {
+		return errs.ErrInvalidRole
+	}
+	return repository.SetUserRole(userID, req.Role, actor, req.Reason)
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/configs"
+	"github.com/hadisjane/confessly/internal/errs"
+	"github.com/hadisjane/confessly/internal/models"
+	"github.com/hadisjane/confessly/internal/repository"
+	"github.com/hadisjane/confessly/utils"
+)
+
+const (
+	recoveryCodeCount = 10
+	// maxLoginChallengeAttempts wrong codes end a login challenge, the
+	// password has to be entered again
+	maxLoginChallengeAttempts = 5
+)
+
+// GetTwoFactorStatus returns the 2FA state of a user
+func GetTwoFactorStatus(userID int) (models.TwoFactorStatus, error) {
+	tf, err := repository.GetTwoFactor(userID)
+	if err != nil {
+		return models.TwoFactorStatus{}, err
+	}
+	if tf.EnabledAt == nil {
+		return models.TwoFactorStatus{}, nil
+	}
+
+	left, err := repository.CountRecoveryCodes(userID)
+	if err != nil {
+		return models.TwoFactorStatus{}, err
+	}
+	return models.TwoFactorStatus{Enabled: true, RecoveryCodesLeft: left}, nil
+}
+
+// SetupTwoFactor starts enrollment with a new secret. Until it is confirmed
+// with ConfirmTwoFactor, calling this again replaces the secret.
+func SetupTwoFactor(userID int) (models.TwoFactorSetup, error) {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return models.TwoFactorSetup{}, err
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return models.TwoFactorSetup{}, err
+	}
+	if err := repository.SetTOTPSecret(userID, secret); err != nil {
+		return models.TwoFactorSetup{}, err
+	}
+
+	return models.TwoFactorSetup{
+		Secret:     secret,
+		OTPAuthURL: utils.TOTPURI(configs.AppSettings.AppParams.ServerName, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor turns 2FA on with a code for the pending secret and
+// returns the recovery codes
+func ConfirmTwoFactor(userID int, code string) ([]string, error) {
+	tf, err := repository.GetTwoFactor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf.EnabledAt != nil {
+		return nil, errs.ErrTwoFactorAlreadyEnabled
+	}
+	if tf.Secret == nil {
+		return nil, errs.ErrTwoFactorSetupMissing
+	}
+
+	step, ok := utils.VerifyTOTP(*tf.Secret, code, time.Now())
+	if !ok {
+		return nil, errs.ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.EnableTwoFactor(userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor turns 2FA off. It takes a current code or a recovery code.
+func DisableTwoFactor(userID int, code string) error {
+	if err := verifyTwoFactorCode(userID, code); err != nil {
+		return err
+	}
+	return repository.DisableTwoFactor(userID)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user. It takes a
+// current code or a recovery code.
+func RegenerateRecoveryCodes(userID int, code string) ([]string, error) {
+	if err := verifyTwoFactorCode(userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// HasTwoFactor reports whether a user has 2FA on
+func HasTwoFactor(userID int) (bool, error) {
+	tf, err := repository.GetTwoFactor(userID)
+	if err != nil {
+		return false, err
+	}
+	return tf.EnabledAt != nil, nil
+}
+
+// CheckTwoFactorRequirement returns ErrTwoFactorRequired for a staff account
+// without 2FA when auth_params.require_two_factor is on
+func CheckTwoFactorRequirement(userID int, role string) error {
+	if !configs.AppSettings.AuthParams.RequireTwoFactor || len(models.RolePermissions[role]) == 0 {
+		return nil
+	}
+
+	enabled, err := HasTwoFactor(userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return errs.ErrTwoFactorRequired
+	}
+	return nil
+}
+
+// StartLoginChallenge hands out the token for the second step of a login
+func StartLoginChallenge(user models.User) (models.TwoFactorChallenge, error) {
+	token, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return models.TwoFactorChallenge{}, err
+	}
+
+	ttl := configs.AppSettings.AuthParams.TwoFactorChallengeTtlSeconds
+	expiresAt := time.Now().UTC().Add(time.Duration(ttl) * time.Second)
+	if err := repository.CreateLoginChallenge(user.ID, hash, expiresAt); err != nil {
+		return models.TwoFactorChallenge{}, err
+	}
+
+	return models.TwoFactorChallenge{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresIn:         ttl,
+	}, nil
+}
+
+// CompleteLoginChallenge checks the code for a login challenge and returns
+// the user to issue tokens for. A challenge allows maxLoginChallengeAttempts
+// codes in total, and every wrong code is also a failed login of the account,
+// so new challenges don't bring fresh guesses once it is locked.
+func CompleteLoginChallenge(token string, code string, ip string) (models.User, error) {
+	challenge, err := repository.ReserveLoginChallengeAttempt(utils.HashToken(token), maxLoginChallengeAttempts)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user, err := repository.GetUserByID(challenge.UserID)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if err := verifyLoginTwoFactorCode(user, code, ip); err != nil {
+		return models.User{}, err
+	}
+
+	if err := repository.CompleteLoginChallenge(challenge.ID); err != nil {
+		return models.User{}, err
+	}
+
+	// A ban may have come in between the two steps
+	if err := CheckUserBan(user.ID, models.BanScopeLogin); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// verifyLoginTwoFactorCode is verifyTwoFactorCode for the steps that only
+// need a password before it. While the account or the IP is locked out no code
+// is checked, and a wrong code counts as a failed login.
+func verifyLoginTwoFactorCode(user models.User, code string, ip string) error {
+	blocked, err := loginBlocked(user.Username, ip)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return errs.ErrInvalidTwoFactorCode
+	}
+
+	err = verifyTwoFactorCode(user.ID, code)
+	if errors.Is(err, errs.ErrInvalidTwoFactorCode) {
+		if err := recordLoginFailure(user.Username, &user.ID, ip); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// verifyTwoFactorCode accepts a code from the authenticator app or an unused
+// recovery code. Either works only once.
+func verifyTwoFactorCode(userID int, code string) error {
+	tf, err := repository.GetTwoFactor(userID)
+	if err != nil {
+		return err
+	}
+	if tf.EnabledAt == nil || tf.Secret == nil {
+		return errs.ErrTwoFactorNotEnabled
+	}
+
+	if step, ok := utils.VerifyTOTP(*tf.Secret, code, time.Now()); ok {
+		return repository.UseTOTPStep(userID, step)
+	}
+	return repository.UseRecoveryCode(userID, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashToken(utils.NormalizeRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
@@ -8,48 +8,64 @@ import (
 	"errors"
 )
 
-func CreateUser(u models.UserRegister) error {
+// CreateUser registers a user and mails them a link to verify their email.
+// With u.ClaimGuest set it also claims the guest identity guestUUID and
+// returns how many confessions it moved.
+func CreateUser(u models.UserRegister, guestUUID string) (int64, error) {
 	_, err := repository.GetUserByUsername(u.Username)
 	if err != nil {
 		if errors.Is(err, errs.ErrNotFound) {
 			// User doesn't exist, we can proceed with creation
 		} else {
-			return err
+			return 0, err
 		}
 	} else {
-		return errs.ErrUserAlreadyExists
+		return 0, errs.ErrUserAlreadyExists
+	}
+
+	var claimGuestUUID *string
+	if u.ClaimGuest {
+		if err := checkGuestClaimable(guestUUID); err != nil {
+			return 0, err
+		}
+		claimGuestUUID = &guestUUID
 	}
 
 	hashedPassword, err := utils.HashPassword(u.Password)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	u.Password = hashedPassword
 
-	if err = repository.CreateUser(u); err != nil {
-		return err
+	userID, claimed, err := repository.CreateUser(u, claimGuestUUID)
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	user := models.User{ID: userID, Username: u.Username, Email: u.Email}
+	inBackground("verification email", func() error {
+		return sendVerificationEmail(user)
+	})
+
+	return claimed, nil
 }
 
-func GetUserByUsernameAndPassword(username string, password string) (models.User, error) {
-	user, err := repository.GetUserByUsername(username)
+// GetUserByUsernameAndPassword logs a user in from ip. Failed attempts are
+// throttled per username and per IP.
+func GetUserByUsernameAndPassword(username string, password string, ip string) (models.User, error) {
+	user, err := authenticate(username, password, ip)
 	if err != nil {
-		if errors.Is(err, errs.ErrNotFound) {
-			return models.User{}, errs.ErrIncorrectUsernameOrPassword
-		}
 		return models.User{}, err
 	}
 
 	// Check if user is banned
-	if user.Banned {
-		return models.User{}, errs.ErrUserBanned
+	if err := CheckUserBan(user.ID, models.BanScopeLogin); err != nil {
+		return models.User{}, err
 	}
 
-	err = utils.VerifyPassword(user.Password, password)
-	if err != nil {
-		return models.User{}, errs.ErrIncorrectUsernameOrPassword
+	// Known passwords such as the old seeded admin/admin have to go first
+	if user.PasswordChangeRequired {
+		return models.User{}, errs.ErrPasswordChangeRequired
 	}
 
 	return user, nil
@@ -64,6 +80,3 @@ func GetUser(id int) (models.User, error) {
 	return user, nil
 }
 
-func IsUserBanned(id int) (bool, error) {
-	return repository.IsUserBanned(id)
-}
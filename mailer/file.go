@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hadisjane/confessly/logger"
+)
+
+// FileMailer appends every message to a file instead of sending it. Meant
+// for local development and tests, where the links in the emails are
+// picked up from the file.
+type FileMailer struct {
+	Path string
+	From string
+	mu   sync.Mutex
+}
+
+func NewFileMailer(path, from string) (*FileMailer, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create mail directory: %w", err)
+		}
+	}
+	return &FileMailer{Path: path, From: from}, nil
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	// Composing catches the same bad addresses an SMTP server would reject,
+	// but the file gets the readable body rather than the encoded one
+	if _, err := compose(m.From, msg); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "Date: %s\nFrom: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC1123Z), m.From, msg.To, msg.Subject, msg.Body)
+	return err
+}
+
+// LogMailer writes messages to the info log instead of sending them
+type LogMailer struct {
+	From string
+}
+
+func (m LogMailer) Send(msg Message) error {
+	logger.Info.Printf("Mail from %s to %s, subject %q:\n%s", m.From, msg.To, msg.Subject, msg.Body)
+	return nil
+}
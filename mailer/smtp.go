@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+
+	"github.com/hadisjane/confessly/internal/models"
+)
+
+// SMTPMailer sends through an SMTP server. The connection is upgraded with
+// STARTTLS when the server offers it, credentials are only sent over TLS
+// or to localhost.
+type SMTPMailer struct {
+	Addr string
+	From string
+	Auth smtp.Auth // nil when the server doesn't need authentication
+}
+
+func NewSMTPMailer(params models.MailParams) *SMTPMailer {
+	m := &SMTPMailer{
+		Addr: net.JoinHostPort(params.SMTPHost, strconv.Itoa(params.SMTPPort)),
+		From: params.From,
+	}
+	if params.SMTPUsername != "" {
+		m.Auth = smtp.PlainAuth("", params.SMTPUsername, params.SMTPPassword, params.SMTPHost)
+	}
+	return m
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	data, err := compose(m.From, msg)
+	if err != nil {
+		return err
+	}
+
+	// Both were validated by compose
+	sender, _ := mail.ParseAddress(m.From)
+	recipient, _ := mail.ParseAddress(msg.To)
+
+	return smtp.SendMail(m.Addr, m.Auth, sender.Address, []string{recipient.Address}, data)
+}
@@ -5,8 +5,13 @@ import (
 	"github.com/hadisjane/confessly/internal/configs"
 	"github.com/hadisjane/confessly/internal/controller"
 	"github.com/hadisjane/confessly/internal/db"
+	"github.com/hadisjane/confessly/internal/service"
 	"github.com/hadisjane/confessly/logger"
+	"github.com/hadisjane/confessly/mailer"
+	"flag"
+	"fmt"
 	"log"
+	"os"
 )
 
 // @title Confessly API
@@ -18,9 +23,24 @@ import (
 // @in header
 // @name Authorization
 
+// configPath is set by the --config flag
+var configPath string
+
 func main() {
+	flag.StringVar(&configPath, "config", "", "path to the JSON config file (default $"+configs.ConfigPathEnv+" or "+configs.DefaultConfigPath+")")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// CLI subcommands (migrate, ...)
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args()))
+	}
+
 	// Load configurations
-	if err := configs.ReadSettings(); err != nil {
+	if err := configs.ReadSettings(configPath); err != nil {
 		log.Fatalf("Failed to load configurations: %v", err)
 	}
 
@@ -29,23 +49,48 @@ func main() {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
 
+	// Initialize the mailer for verification and password reset emails
+	if err := mailer.Init(); err != nil {
+		logger.Error.Fatalf("Failed to initialize mailer: %v", err)
+	}
+
 	// Initialize database connection
 	if err := db.ConnDB(); err != nil {
 		logger.Error.Fatalf("Error connecting to database: %v", err)
 	}
 	logger.Info.Println("Database connection established successfully")
 
-	// Run schema initialization
-	if err := db.InitMigrations(); err != nil {
-		logger.Error.Fatalf("Error initializing database schema: %v", err)
+	// Apply pending schema migrations
+	applied, err := db.MigrateUp()
+	if err != nil {
+		logger.Error.Fatalf("Error applying database migrations: %v", err)
+	}
+	logger.Info.Printf("Database schema is up to date (%d migration(s) applied)", applied)
+
+	// Full-text search column depends on the configured languages and is
+	// only rebuilt when they change
+	if err := db.SyncSearchColumn(); err != nil {
+		logger.Error.Fatalf("Error preparing full-text search: %v", err)
+	}
+
+	// Confessions posted before fingerprints were stored can't be compared yet
+	fingerprinted, err := service.BackfillFingerprints()
+	if err != nil {
+		logger.Error.Fatalf("Error fingerprinting confessions: %v", err)
+	}
+	if fingerprinted > 0 {
+		logger.Info.Printf("Fingerprinted %d confession(s)", fingerprinted)
+	}
+
+	// The admin/admin account seeded by older versions must not stay usable
+	if err := service.CheckDefaultCredentials(); err != nil {
+		logger.Error.Fatalf("Refusing to start: %v", err)
 	}
-	logger.Info.Println("Database schema initialized successfully")
 
-	// Seed database
-	if err := db.SeedDB(); err != nil {
-		logger.Error.Fatalf("Error seeding database: %v", err)
+	// A fresh database gets its first owner through a one-time token
+	if err := service.StartBootstrap(); err != nil {
+		logger.Error.Fatalf("Error preparing the bootstrap token: %v", err)
 	}
-	logger.Info.Println("Database seeded successfully")
 
 	// Start the server
 	if err := controller.RunServer(); err != nil {
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/configs"
+
+	"github.com/google/uuid"
+)
+
+// challengeTokenVersion открывает каждый токен задачи proof-of-work. Токен
+// имеет вид c1.<id ключа>.<nonce>.<uuid гостя>.<сложность>.<срок>.<подпись>
+const challengeTokenVersion = "c1"
+
+var ErrInvalidChallenge = errors.New("invalid challenge")
+
+// ChallengeToken — проверенное содержимое токена задачи
+type ChallengeToken struct {
+	Nonce      string
+	GuestUUID  string
+	Difficulty int
+	ExpiresAt  time.Time
+}
+
+// SignChallenge подписывает задачу для гостя текущим ключом гостевых cookie
+func SignChallenge(nonce string, guestUUID string, difficulty int, expiresAt time.Time) string {
+	key := configs.AppSettings.GuestParams.CookieKeys[0]
+	payload := strings.Join([]string{
+		challengeTokenVersion,
+		guestKeyID(key),
+		nonce,
+		guestUUID,
+		strconv.Itoa(difficulty),
+		strconv.FormatInt(expiresAt.Unix(), 10),
+	}, ".")
+	return payload + "." + guestSignature(key, payload)
+}
+
+// ParseChallenge проверяет подпись токена задачи. Срок действия проверяет
+// вызывающий, чтобы отличать просроченные задачи от поддельных.
+func ParseChallenge(token string) (ChallengeToken, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 7 || parts[0] != challengeTokenVersion {
+		return ChallengeToken{}, ErrInvalidChallenge
+	}
+
+	key, ok := guestKey(parts[1])
+	if !ok {
+		return ChallengeToken{}, ErrInvalidChallenge
+	}
+
+	payload := strings.Join(parts[:6], ".")
+	if !hmacEqual(parts[6], guestSignature(key, payload)) {
+		return ChallengeToken{}, ErrInvalidChallenge
+	}
+
+	if _, err := uuid.Parse(parts[3]); err != nil {
+		return ChallengeToken{}, ErrInvalidChallenge
+	}
+	difficulty, err := strconv.Atoi(parts[4])
+	if err != nil {
+		return ChallengeToken{}, ErrInvalidChallenge
+	}
+	expiresAt, err := strconv.ParseInt(parts[5], 10, 64)
+	if err != nil {
+		return ChallengeToken{}, ErrInvalidChallenge
+	}
+
+	return ChallengeToken{
+		Nonce:      parts[2],
+		GuestUUID:  parts[3],
+		Difficulty: difficulty,
+		ExpiresAt:  time.Unix(expiresAt, 0),
+	}, nil
+}
+
+// CheckProofOfWork сообщает, начинается ли SHA-256 от "<токен>:<решение>"
+// хотя бы с difficulty нулевых бит
+func CheckProofOfWork(token string, solution string, difficulty int) bool {
+	sum := sha256.Sum256([]byte(token + ":" + solution))
+
+	zeros := 0
+	for _, b := range sum {
+		zeros += bits.LeadingZeros8(b)
+		if b != 0 {
+			break
+		}
+	}
+	return zeros >= difficulty
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseChallenge(t *testing.T) {
+	withGuestKeys(t, "current-key", "old-key")
+
+	expiresAt := time.Unix(2000000000, 0)
+	valid := SignChallenge("nonce", testGuestUUID, 12, expiresAt)
+
+	got, err := ParseChallenge(valid)
+	if err != nil {
+		t.Fatalf("ParseChallenge: %v", err)
+	}
+	want := ChallengeToken{Nonce: "nonce", GuestUUID: testGuestUUID, Difficulty: 12, ExpiresAt: expiresAt}
+	if got != want {
+		t.Errorf("ParseChallenge = %+v, want %+v", got, want)
+	}
+
+	// Истёкшую задачу ParseChallenge не отклоняет, это дело вызывающего
+	expired := SignChallenge("nonce", testGuestUUID, 12, time.Unix(59, 0))
+
+	withGuestKeys(t, "removed-key")
+	unknownKey := SignChallenge("nonce", testGuestUUID, 12, expiresAt)
+	withGuestKeys(t, "current-key", "old-key")
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"истёкшая задача", expired, true},
+		{"ключ удалён из конфигурации", unknownKey, false},
+		{"подменён nonce", replacePart(valid, 2, "other"), false},
+		{"подменён гость", replacePart(valid, 3, "1b8e6c1e-4f5a-4d2b-9c3e-7a1f2d3c4b5a"), false},
+		{"снижена сложность", replacePart(valid, 4, "1"), false},
+		{"продлён срок", replacePart(valid, 5, strconv.FormatInt(expiresAt.Unix()+3600, 10)), false},
+		{"гостевой токен вместо задачи", SignGuestToken(testGuestUUID, time.Now()), false},
+		{"другая версия", replacePart(valid, 0, "c2"), false},
+		{"пустой токен", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseChallenge(tt.token)
+			if (err == nil) != tt.ok {
+				t.Errorf("ParseChallenge(%q) error = %v, want ok %v", tt.token, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCheckProofOfWork(t *testing.T) {
+	const token, difficulty = "challenge", 8
+
+	solution := ""
+	for i := 0; solution == ""; i++ {
+		if CheckProofOfWork(token, strconv.Itoa(i), difficulty) {
+			solution = strconv.Itoa(i)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		difficulty int
+		want       bool
+	}{
+		{"нулевая сложность", token, 0, true},
+		{"найденное решение", token, difficulty, true},
+		{"решение для другой задачи", "other", 64, false},
+		{"больше бит, чем в хеше", token, 257, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckProofOfWork(tt.token, solution, tt.difficulty); got != tt.want {
+				t.Errorf("CheckProofOfWork(%q, %q, %d) = %v, want %v", tt.token, solution, tt.difficulty, got, tt.want)
+			}
+		})
+	}
+}
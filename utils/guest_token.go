@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/configs"
+
+	"github.com/google/uuid"
+)
+
+// guestTokenVersion открывает каждый подписанный гостевой токен. Токен имеет
+// вид v1.<id ключа>.<uuid>.<время выдачи>.<подпись>
+const guestTokenVersion = "v1"
+
+var ErrInvalidGuestToken = errors.New("invalid guest token")
+
+// GuestToken — проверенное содержимое гостевой cookie
+type GuestToken struct {
+	UUID     string
+	IssuedAt time.Time
+	KeyID    string
+}
+
+// GuestCookieTTL возвращает время жизни гостевой cookie из guest_params.cookie_max_age_days
+func GuestCookieTTL() time.Duration {
+	return time.Duration(configs.AppSettings.GuestParams.CookieMaxAgeDays) * 24 * time.Hour
+}
+
+// SignGuestToken подписывает UUID гостя текущим (первым) ключом из guest_params.cookie_keys
+func SignGuestToken(guestUUID string, issuedAt time.Time) string {
+	key := configs.AppSettings.GuestParams.CookieKeys[0]
+	payload := strings.Join([]string{
+		guestTokenVersion,
+		guestKeyID(key),
+		guestUUID,
+		strconv.FormatInt(issuedAt.Unix(), 10),
+	}, ".")
+	return payload + "." + guestSignature(key, payload)
+}
+
+// ParseGuestToken проверяет подпись и срок гостевого токена. Принимаются
+// токены, подписанные любым ключом из guest_params.cookie_keys, поэтому ключ
+// можно сменить, не теряя гостей.
+func ParseGuestToken(token string) (GuestToken, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 || parts[0] != guestTokenVersion {
+		return GuestToken{}, ErrInvalidGuestToken
+	}
+
+	key, ok := guestKey(parts[1])
+	if !ok {
+		return GuestToken{}, ErrInvalidGuestToken
+	}
+
+	payload := strings.Join(parts[:4], ".")
+	if !hmacEqual(parts[4], guestSignature(key, payload)) {
+		return GuestToken{}, ErrInvalidGuestToken
+	}
+
+	parsed, err := uuid.Parse(parts[2])
+	if err != nil || parsed.String() != parts[2] {
+		return GuestToken{}, ErrInvalidGuestToken
+	}
+
+	issuedAt, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return GuestToken{}, ErrInvalidGuestToken
+	}
+
+	result := GuestToken{
+		UUID:     parts[2],
+		IssuedAt: time.Unix(issuedAt, 0),
+		KeyID:    parts[1],
+	}
+	if time.Since(result.IssuedAt) > GuestCookieTTL() {
+		return GuestToken{}, ErrInvalidGuestToken
+	}
+	return result, nil
+}
+
+// SignedWithCurrentKey сообщает, подписан ли токен ключом, которым подписываются новые токены
+func (t GuestToken) SignedWithCurrentKey() bool {
+	return t.KeyID == guestKeyID(configs.AppSettings.GuestParams.CookieKeys[0])
+}
+
+// IsLegacyGuestCookie сообщает, что cookie выдана до появления подписи и содержит голый UUID
+func IsLegacyGuestCookie(cookie string) bool {
+	parsed, err := uuid.Parse(cookie)
+	return err == nil && parsed.String() == cookie
+}
+
+// guestKeyID — короткий отпечаток ключа, по которому при проверке находится нужный ключ
+func guestKeyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:4])
+}
+
+func guestKey(keyID string) (string, bool) {
+	for _, key := range configs.AppSettings.GuestParams.CookieKeys {
+		if guestKeyID(key) == keyID {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func hmacEqual(signature, expected string) bool {
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+func guestSignature(key, payload string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
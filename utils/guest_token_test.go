@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hadisjane/confessly/internal/configs"
+	"github.com/hadisjane/confessly/internal/models"
+)
+
+const testGuestUUID = "0b8e6c1e-4f5a-4d2b-9c3e-7a1f2d3c4b5a"
+
+// withGuestKeys подменяет ключи гостевых cookie на время теста
+func withGuestKeys(t *testing.T, keys ...string) {
+	t.Helper()
+	saved := configs.AppSettings.GuestParams
+	t.Cleanup(func() { configs.AppSettings.GuestParams = saved })
+	configs.AppSettings.GuestParams = models.GuestParams{CookieKeys: keys, CookieMaxAgeDays: 30}
+}
+
+// replacePart заменяет i-ю часть токена, оставляя старую подпись
+func replacePart(token string, i int, value string) string {
+	parts := strings.Split(token, ".")
+	parts[i] = value
+	return strings.Join(parts, ".")
+}
+
+func TestParseGuestToken(t *testing.T) {
+	withGuestKeys(t, "current-key", "old-key")
+
+	now := time.Now()
+	valid := SignGuestToken(testGuestUUID, now)
+
+	got, err := ParseGuestToken(valid)
+	if err != nil {
+		t.Fatalf("ParseGuestToken: %v", err)
+	}
+	if got.UUID != testGuestUUID || got.IssuedAt.Unix() != now.Unix() || !got.SignedWithCurrentKey() {
+		t.Errorf("ParseGuestToken = %+v", got)
+	}
+
+	withGuestKeys(t, "removed-key")
+	unknownKey := SignGuestToken(testGuestUUID, now)
+	withGuestKeys(t, "old-key")
+	oldKey := SignGuestToken(testGuestUUID, now)
+	expired := SignGuestToken(testGuestUUID, now.Add(-31*24*time.Hour))
+	nearlyExpired := SignGuestToken(testGuestUUID, now.Add(-29*24*time.Hour))
+	upperUUID := SignGuestToken(strings.ToUpper(testGuestUUID), now)
+	withGuestKeys(t, "current-key", "old-key")
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"подписан старым ключом", oldKey, true},
+		{"срок почти истёк", nearlyExpired, true},
+		{"срок истёк", expired, false},
+		{"ключ удалён из конфигурации", unknownKey, false},
+		{"подменён uuid", replacePart(valid, 2, "1b8e6c1e-4f5a-4d2b-9c3e-7a1f2d3c4b5a"), false},
+		{"подменено время выдачи", replacePart(valid, 3, "9999999999"), false},
+		{"подменён id ключа", replacePart(valid, 1, guestKeyID("old-key")), false},
+		{"подменена подпись", replacePart(valid, 4, guestSignature("other-key", "payload")), false},
+		{"другая версия", replacePart(valid, 0, "v2"), false},
+		{"uuid не в канонической записи", upperUUID, false},
+		{"лишняя часть", valid + ".x", false},
+		{"без подписи", valid[:strings.LastIndex(valid, ".")], false},
+		{"голый uuid", testGuestUUID, false},
+		{"пустой токен", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGuestToken(tt.token)
+			if (err == nil) != tt.ok {
+				t.Errorf("ParseGuestToken(%q) error = %v, want ok %v", tt.token, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSignedWithCurrentKey(t *testing.T) {
+	withGuestKeys(t, "old-key")
+	token := SignGuestToken(testGuestUUID, time.Now())
+
+	withGuestKeys(t, "current-key", "old-key")
+	got, err := ParseGuestToken(token)
+	if err != nil {
+		t.Fatalf("ParseGuestToken: %v", err)
+	}
+	if got.SignedWithCurrentKey() {
+		t.Error("a token signed with the old key reports the current key")
+	}
+}
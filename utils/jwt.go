@@ -2,10 +2,12 @@ package utils
 
 import (
 	"fmt"
-	"os"
 	"time"
 
+	"github.com/hadisjane/confessly/internal/configs"
+
 	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
 )
 
 // CustomClaims определяет кастомные поля токена
@@ -13,33 +15,51 @@ type CustomClaims struct {
 	UserID   int    `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	// IssuedAtMicros — время выпуска в микросекундах. В iat только секунды,
+	// а выход со всех устройств должен отличать токены, выпущенные в ту же секунду.
+	IssuedAtMicros int64 `json:"iat_us,omitempty"`
 	jwt.StandardClaims
 }
 
-// JwtTtlMinutes определяет время жизни токена в минутах
-const JwtTtlMinutes = 60
+// IssuedAtTime возвращает время выпуска токена с точностью до микросекунды,
+// а для токенов без iat_us — с точностью до секунды
+func (c *CustomClaims) IssuedAtTime() time.Time {
+	if c.IssuedAtMicros != 0 {
+		return time.UnixMicro(c.IssuedAtMicros)
+	}
+	return time.Unix(c.IssuedAt, 0)
+}
+
+// AccessTokenTTL возвращает время жизни access токена из auth_params.jwt_ttl_minutes
+func AccessTokenTTL() time.Duration {
+	return time.Duration(configs.AppSettings.AuthParams.JwtTtlMinutes) * time.Minute
+}
 
-// ServerName имя сервиса, используется как эмитент токена
-const ServerName = "Confessly"
+// RefreshTokenTTL возвращает время жизни refresh токена из auth_params.refresh_ttl_hours
+func RefreshTokenTTL() time.Duration {
+	return time.Duration(configs.AppSettings.AuthParams.RefreshTtlHours) * time.Hour
+}
 
-// GetJWTSecretKey возвращает секретный ключ для подписи JWT токенов
+// GetJWTSecretKey возвращает секретный ключ для подписи JWT токенов.
+// Наличие ключа проверяется при загрузке конфигурации.
 func GetJWTSecretKey() string {
-	key := os.Getenv("JWT_SECRET_KEY")
-	if key == "" {
-		panic("JWT_SECRET_KEY environment variable is not set")
-	}
-	return key
+	return configs.AppSettings.AuthParams.JwtSecretKey
 }
 
-// GenerateToken генерирует JWT токен с кастомными полями
+// GenerateToken генерирует JWT токен с кастомными полями.
+// Каждый токен получает уникальный jti, по которому его можно отозвать.
 func GenerateToken(userID int, username string, role string) (string, error) {
+	now := time.Now()
 	claims := CustomClaims{
-		UserID:   userID,
-		Username: username,
-		Role:     role,	
+		UserID:         userID,
+		Username:       username,
+		Role:           role,
+		IssuedAtMicros: now.UnixMicro(),
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Minute * JwtTtlMinutes).Unix(), // токен истекает через JwtTtlMinutes минут
-			Issuer:    ServerName,
+			Id:        uuid.New().String(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(AccessTokenTTL()).Unix(),
+			Issuer:    configs.AppSettings.AppParams.ServerName, // имя сервиса, эмитент токена
 		},
 	}
 
@@ -62,6 +82,10 @@ func ParseToken(tokenString string) (*CustomClaims, error) {
 	}
 
 	if claims, ok := token.Claims.(*CustomClaims); ok && token.Valid {
+		// Токены без jti нельзя отозвать, поэтому не принимаем их
+		if claims.Id == "" {
+			return nil, fmt.Errorf("token has no id")
+		}
 		return claims, nil
 	}
 
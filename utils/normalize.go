@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// confusables заменяют символы, похожие на латинские буквы, самими буквами,
+// чтобы смешение кириллицы, греческого и латиницы не обходило фильтры.
+// Цифры и знаки из «leet» тоже считаются буквами.
+var confusables = map[rune]rune{
+	// кириллица
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'и': 'u', 'й': 'u', 'к': 'k',
+	'м': 'm', 'н': 'h', 'о': 'o', 'п': 'n', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y',
+	'х': 'x', 'ь': 'b', 'і': 'i', 'ї': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	// греческий
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'γ': 'y', 'ω': 'w',
+	// leet
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's',
+}
+
+// NormalizedText — текст в двух видах для сравнения с фильтрами. Folded
+// разложен по NFKD, без диакритики и невидимых символов, в нижнем регистре.
+// Skeleton — тот же Folded, где похожие символы заменены латиницей. Обе
+// строки совпадают по числу рун, и для каждой руны известна исходная.
+type NormalizedText struct {
+	Folded   string
+	Skeleton string
+	origin   []int
+	original []rune
+}
+
+// NormalizeText приводит текст к виду, в котором его сравнивают с фильтрами
+func NormalizeText(s string) NormalizedText {
+	text := NormalizedText{original: []rune(s)}
+
+	var folded, skeleton strings.Builder
+	for i, r := range text.original {
+		for _, d := range norm.NFKD.String(string(r)) {
+			// Диакритика и невидимые символы вроде нулевого пробела пропадают
+			if unicode.Is(unicode.Mn, d) || unicode.Is(unicode.Cf, d) {
+				continue
+			}
+			d = unicode.ToLower(d)
+			folded.WriteRune(d)
+			if c, ok := confusables[d]; ok {
+				d = c
+			}
+			skeleton.WriteRune(d)
+			text.origin = append(text.origin, i)
+		}
+	}
+
+	text.Folded = folded.String()
+	text.Skeleton = skeleton.String()
+	return text
+}
+
+// NormalizePattern приводит слово из фильтра к тому же виду, что Skeleton
+func NormalizePattern(s string) string {
+	return NormalizeText(strings.TrimSpace(s)).Skeleton
+}
+
+// FindRegexp ищет выражение и в Folded, и в Skeleton, так что правило на
+// кириллице находит кириллицу, а правило на латинице — ещё и её двойников.
+// Возвращает начало и конец каждого вхождения в рунах.
+func (t NormalizedText) FindRegexp(re *regexp.Regexp) [][2]int {
+	var spans [][2]int
+	for _, s := range []string{t.Folded, t.Skeleton} {
+		for _, loc := range re.FindAllStringIndex(s, -1) {
+			span := [2]int{utf8.RuneCountInString(s[:loc[0]]), utf8.RuneCountInString(s[:loc[1]])}
+			// В латинском тексте обе строки совпадают и находят одно и то же
+			if !slices.Contains(spans, span) {
+				spans = append(spans, span)
+			}
+		}
+	}
+	return spans
+}
+
+// FindWord ищет в Skeleton слово word целиком, а не часть другого слова.
+// Возвращает начало и конец каждого вхождения в рунах.
+func (t NormalizedText) FindWord(word string) [][2]int {
+	var spans [][2]int
+	text := []rune(t.Skeleton)
+	pattern := []rune(word)
+	if len(pattern) == 0 {
+		return nil
+	}
+
+	for i := 0; i+len(pattern) <= len(text); i++ {
+		if string(text[i:i+len(pattern)]) != word {
+			continue
+		}
+		end := i + len(pattern)
+		if (i > 0 && isWordRune(text[i-1])) || (end < len(text) && isWordRune(text[end])) {
+			continue
+		}
+		spans = append(spans, [2]int{i, end})
+	}
+	return spans
+}
+
+// Mask заменяет звёздочками исходные символы, попавшие в промежутки spans
+func (t NormalizedText) Mask(spans [][2]int) string {
+	masked := make([]rune, len(t.original))
+	copy(masked, t.original)
+
+	for _, span := range spans {
+		if span[0] >= span[1] {
+			continue
+		}
+		// Вместе со словом закрываются и невидимые символы внутри него
+		for i := t.origin[span[0]]; i <= t.origin[span[1]-1]; i++ {
+			if !unicode.IsSpace(masked[i]) {
+				masked[i] = '*'
+			}
+		}
+	}
+	return string(masked)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
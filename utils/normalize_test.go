@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		folded   string
+		skeleton string
+	}{
+		{"латиница", "Hello", "hello", "hello"},
+		{"кириллица", "Привет", "привет", "npubet"},
+		{"смешанный алфавит", "bаd", "bаd", "bad"},
+		{"греческий", "ΒΑΔ", "βαδ", "baδ"},
+		{"диакритика", "Café", "cafe", "cafe"},
+		{"нулевой пробел", "b​ad", "bad", "bad"},
+		{"полноширинные", "ＢＡＤ", "bad", "bad"},
+		{"leet", "p4$$w0rd", "p4$$w0rd", "password"},
+		{"пустая строка", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeText(tt.in)
+			if got.Folded != tt.folded {
+				t.Errorf("Folded = %q, want %q", got.Folded, tt.folded)
+			}
+			if got.Skeleton != tt.skeleton {
+				t.Errorf("Skeleton = %q, want %q", got.Skeleton, tt.skeleton)
+			}
+		})
+	}
+}
+
+func TestFindWord(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		word string
+		want [][2]int
+	}{
+		{"целое слово", "a bad day", "bad", [][2]int{{2, 5}}},
+		{"часть другого слова", "badge and sinbad", "bad", nil},
+		{"несколько вхождений", "bad, bad!", "bad", [][2]int{{0, 3}, {5, 8}}},
+		{"двойники букв", "so bаd", "bad", [][2]int{{3, 6}}},
+		{"leet", "so b4d", "bad", [][2]int{{3, 6}}},
+		{"невидимый символ внутри", "b​ad", "bad", [][2]int{{0, 3}}},
+		{"регистр", "BAD", "bad", [][2]int{{0, 3}}},
+		{"пустое слово", "bad", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeText(tt.text).FindWord(tt.word)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FindWord(%q) in %q = %v, want %v", tt.word, tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		word string
+		want string
+	}{
+		{"слово", "a bad day", "bad", "a *** day"},
+		{"исходные символы", "so bаd", "bad", "so ***"},
+		{"невидимый символ внутри", "ok b​ad ok", "bad", "ok **** ok"},
+		{"диакритика", "a bád day", "bad", "a *** day"},
+		{"нет вхождений", "a good day", "bad", "a good day"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := NormalizeText(tt.text)
+			if got := text.Mask(text.FindWord(tt.word)); got != tt.want {
+				t.Errorf("Mask = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
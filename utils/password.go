@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MinStrongPasswordLength — минимальная длина пароля для аккаунтов персонала
+const MinStrongPasswordLength = 12
+
+// commonPasswordParts — то, с чего начинают подбор, поэтому пароль не должен
+// это содержать
+var commonPasswordParts = []string{
+	"password", "passw0rd", "qwerty", "123456", "654321", "111111", "abc123",
+	"letmein", "welcome", "admin", "administrator", "root", "iloveyou", "confessly",
+}
+
+// PasswordWeakness объясняет, чем пароль слаб, или возвращает пустую строку,
+// если он достаточно сильный. Пароль должен быть не короче
+// MinStrongPasswordLength символов, не содержать имя пользователя, часть email
+// до @ и распространённые пароли и состоять хотя бы из трёх классов символов
+// (строчные, прописные, цифры, остальные). Длинной парольной фразе от 20
+// символов классы не нужны.
+func PasswordWeakness(password string, username string, email string) string {
+	length := utf8.RuneCountInString(password)
+	if length < MinStrongPasswordLength {
+		return fmt.Sprintf("must be at least %d characters long", MinStrongPasswordLength)
+	}
+
+	lower := strings.ToLower(password)
+	for _, part := range commonPasswordParts {
+		if strings.Contains(lower, part) {
+			return "must not contain a common password"
+		}
+	}
+
+	local, _, _ := strings.Cut(email, "@")
+	for _, personal := range []string{username, local} {
+		if utf8.RuneCountInString(personal) >= 3 && strings.Contains(lower, strings.ToLower(personal)) {
+			return "must not contain the username or email"
+		}
+	}
+
+	var hasLower, hasUpper, hasDigit, hasOther bool
+	distinct := make(map[rune]bool)
+	for _, r := range password {
+		distinct[r] = true
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasOther = true
+		}
+	}
+	if len(distinct) < 6 {
+		return "must not repeat the same few characters"
+	}
+
+	classes := 0
+	for _, has := range []bool{hasLower, hasUpper, hasDigit, hasOther} {
+		if has {
+			classes++
+		}
+	}
+	if classes < 3 && length < 20 {
+		return "must mix at least three of lowercase, uppercase, digits and symbols, or be at least 20 characters long"
+	}
+
+	return ""
+}
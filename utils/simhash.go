@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// simHashShingle — длина символьных шинглов. Короткие шинглы почти не
+// меняются от замены пары букв, поэтому отпечатки похожих текстов близки.
+const simHashShingle = 4
+
+// SimHash строит 64-битный отпечаток текста. Текст сначала нормализуется так
+// же, как для фильтров, и из него убираются знаки препинания, так что
+// регистр, двойники букв и пунктуация не влияют на отпечаток.
+func SimHash(text string) uint64 {
+	var b strings.Builder
+	space := true
+	for _, r := range NormalizeText(text).Skeleton {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+		} else if !space {
+			b.WriteRune(' ')
+			space = true
+		}
+	}
+	runes := []rune(strings.TrimSpace(b.String()))
+	if len(runes) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	add := func(shingle string) {
+		h := fnv.New64a()
+		h.Write([]byte(shingle))
+		sum := h.Sum64()
+		for i := range weights {
+			if sum&(1<<i) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	if len(runes) < simHashShingle {
+		add(string(runes))
+	}
+	for i := 0; i+simHashShingle <= len(runes); i++ {
+		add(string(runes[i : i+simHashShingle]))
+	}
+
+	var hash uint64
+	for i, w := range weights {
+		if w > 0 {
+			hash |= 1 << i
+		}
+	}
+	return hash
+}
+
+// HammingDistance — число различающихся бит двух отпечатков
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/hadisjane/confessly/internal/configs"
+)
+
+func TestSimHash(t *testing.T) {
+	const text = "Я никому не говорил, что боюсь темноты и сплю со светом"
+	// Похожими считаются тексты не дальше duplicate_params.max_distance по умолчанию
+	maxDistance := configs.Defaults().DuplicateParams.MaxDistance
+
+	tests := []struct {
+		name  string
+		other string
+		max   int // наибольшее допустимое расстояние Хэмминга
+	}{
+		{"тот же текст", text, 0},
+		{"регистр и пунктуация", "я НИКОМУ не говорил... что боюсь темноты, и сплю со светом!", 0},
+		{"латинские двойники", "Я никому не гoвoрил, чтo бoюсь темнoты и сплю сo светoм", 0},
+		{"лишние пробелы", "  Я никому   не говорил, что боюсь темноты и сплю со светом ", 0},
+		{"одна опечатка", "Я никому не говорил, что боюсь темноты и сплю со свитом", maxDistance},
+	}
+
+	hash := SimHash(text)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d := HammingDistance(hash, SimHash(tt.other)); d > tt.max {
+				t.Errorf("distance to %q = %d, want at most %d", tt.other, d, tt.max)
+			}
+		})
+	}
+
+	unrelated := SimHash("Вчера я впервые пробежал марафон и теперь не могу подняться по лестнице")
+	if d := HammingDistance(hash, unrelated); d <= maxDistance {
+		t.Errorf("distance to an unrelated text = %d, want more than %d", d, maxDistance)
+	}
+}
+
+func TestSimHashEmpty(t *testing.T) {
+	for _, text := range []string{"", "   ", "?!..."} {
+		if got := SimHash(text); got != 0 {
+			t.Errorf("SimHash(%q) = %x, want 0", text, got)
+		}
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0b1011, 0b0001, 2},
+		{0, ^uint64(0), 64},
+	}
+	for _, tt := range tests {
+		if got := HammingDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("HammingDistance(%b, %b) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
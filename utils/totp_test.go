@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret — ключ SHA1 из приложения B RFC 6238 ("12345678901234567890") в base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Тестовые векторы RFC 6238 для SHA1. В RFC коды из 8 цифр, у нас из 6 —
+// это их последние 6 цифр.
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestTOTPCode(t *testing.T) {
+	for _, tt := range rfc6238Vectors {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode at %d: %v", tt.unix, err)
+		}
+		if got != tt.code {
+			t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	for _, tt := range rfc6238Vectors {
+		now := time.Unix(tt.unix, 0)
+		step, ok := VerifyTOTP(rfc6238Secret, tt.code, now)
+		if !ok || step != tt.unix/totpPeriod {
+			t.Errorf("VerifyTOTP(%s) at %d = %d, %v, want %d, true", tt.code, tt.unix, step, ok, tt.unix/totpPeriod)
+		}
+	}
+
+	// 1111111111 приходится на середину шага, поэтому ±30 секунд — соседние шаги
+	at := time.Unix(1111111111, 0)
+	tests := []struct {
+		name string
+		code string
+		now  time.Time
+		want bool
+	}{
+		{"пробелы в коде", " 050 471 ", at, true},
+		{"предыдущий шаг", "050471", at.Add(totpPeriod * time.Second), true},
+		{"следующий шаг", "050471", at.Add(-totpPeriod * time.Second), true},
+		{"через два шага", "050471", at.Add(2 * totpPeriod * time.Second), false},
+		{"два шага назад", "050471", at.Add(-2 * totpPeriod * time.Second), false},
+		{"неверный код", "050472", at, false},
+		{"код из 8 цифр", "07081804", time.Unix(1111111109, 0), false},
+		{"короткий код", "05047", at, false},
+		{"пустой код", "", at, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := VerifyTOTP(rfc6238Secret, tt.code, tt.now); ok != tt.want {
+				t.Errorf("VerifyTOTP(%q) = %v, want %v", tt.code, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyTOTPInvalidSecret(t *testing.T) {
+	if _, ok := VerifyTOTP("not base32!", "123456", time.Now()); ok {
+		t.Error("VerifyTOTP accepted a code for a malformed secret")
+	}
+}